@@ -0,0 +1,89 @@
+package exif
+
+import (
+	"io/fs"
+	"sort"
+	"strings"
+
+	"github.com/lkzz/exif/exiftag"
+)
+
+// Analytics is the distribution of the metadata of a tree of images, for
+// capacity planning before enabling stripping. It is suitable for JSON.
+type Analytics struct {
+	Images  int `json:"images"`
+	Skipped int `json:"skipped"`
+	// Orientations count the images by orientation, 0 for none.
+	Orientations map[int]int `json:"orientations"`
+	// Models count the images by camera, "Make Model", "" for none.
+	Models map[string]int `json:"models"`
+	// GPSRate is the fraction of images with a location.
+	GPSRate float64 `json:"gps_rate"`
+	// MetadataSize is the distribution of the metadata segments size per image.
+	MetadataSize Percentiles `json:"metadata_size"`
+}
+
+// Percentiles summarize a distribution of sizes in bytes.
+type Percentiles struct {
+	P50 int64 `json:"p50"`
+	P90 int64 `json:"p90"`
+	P99 int64 `json:"p99"`
+	Max int64 `json:"max"`
+}
+
+// AnalyzeFS return the metadata analytics of the jpeg images found walking
+// fsys, files and directories which can not be read or parsed are counted
+// as skipped.
+func AnalyzeFS(fsys fs.FS) (a *Analytics, err error) {
+	defer recoverCorrupt(&err)
+	a = &Analytics{Orientations: make(map[int]int), Models: make(map[string]int)}
+	var (
+		sizes []int64
+		gps   int
+	)
+	err = walkJPEG(fsys, func(in []byte, err error) {
+		var s Stats
+		if err != nil || s.Add(in) != nil {
+			a.Skipped++
+			return
+		}
+		a.Images++
+		var size int64
+		for _, n := range s.Segments {
+			size += n
+		}
+		sizes = append(sizes, size)
+		e, derr := Decode(in)
+		if derr != nil {
+			a.Orientations[0]++
+			a.Models[""]++
+			return
+		}
+		o, _ := Get[uint16](e, exiftag.Orientation)
+		a.Orientations[int(o)]++
+		mk, _ := Get[string](e, exiftag.Make)
+		model, _ := Get[string](e, exiftag.Model)
+		a.Models[strings.TrimSpace(mk+" "+model)]++
+		if _, lerr := e.Location(); lerr == nil {
+			gps++
+		}
+	})
+	if a.Images > 0 {
+		a.GPSRate = float64(gps) / float64(a.Images)
+	}
+	a.MetadataSize = percentiles(sizes)
+	return
+}
+
+// percentiles return the nearest-rank percentiles of sizes.
+func percentiles(sizes []int64) (p Percentiles) {
+	if len(sizes) == 0 {
+		return
+	}
+	sort.Slice(sizes, func(i, j int) bool { return sizes[i] < sizes[j] })
+	rank := func(q int) int64 {
+		i := (q*len(sizes)+99)/100 - 1
+		return sizes[max(i, 0)]
+	}
+	return Percentiles{P50: rank(50), P90: rank(90), P99: rank(99), Max: sizes[len(sizes)-1]}
+}
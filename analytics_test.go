@@ -0,0 +1,33 @@
+package exif
+
+import (
+	"encoding/json"
+	"testing"
+	"testing/fstest"
+)
+
+func TestAnalyzeFS(t *testing.T) {
+	stripped, _ := StripAll(readFixture(t, filename))
+	fsys := fstest.MapFS{
+		"a/big.jpg":    {Data: readFixture(t, "exif_bigEndian.jpg")},
+		"a/little.jpg": {Data: readFixture(t, "exif_littleEndian.jpg")},
+		"b/none.JPG":   {Data: stripped},
+		"b/bad.jpg":    {Data: []byte("x")},
+	}
+	a, err := AnalyzeFS(fsys)
+	if err != nil {
+		t.Fatalf("AnalyzeFS error(%v)", err)
+	}
+	if a.Images != 3 || a.Skipped != 1 || a.Orientations[6] != 2 || a.Orientations[0] != 1 || a.Models[""] != 1 {
+		t.Fatalf("AnalyzeFS = %+v", a)
+	}
+	if a.MetadataSize.Max == 0 || a.MetadataSize.P50 > a.MetadataSize.Max {
+		t.Fatalf("MetadataSize = %+v", a.MetadataSize)
+	}
+	if _, err = json.Marshal(a); err != nil {
+		t.Fatalf("json.Marshal error(%v)", err)
+	}
+	if p := percentiles([]int64{5, 1, 4, 2, 3}); p != (Percentiles{P50: 3, P90: 5, P99: 5, Max: 5}) {
+		t.Fatalf("percentiles = %+v", p)
+	}
+}
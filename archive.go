@@ -0,0 +1,77 @@
+package exif
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// Archiver keep the metadata a Processor remove from images, for an
+// auditable archive of what was removed. It must be safe for concurrent use.
+type Archiver interface {
+	// Archive keep the metadata removed from the image which output has the
+	// SHA-256 key. metadata is a jpeg holding only the removed segments, in
+	// their order, so Decode, Keywords or exiftool can read it back.
+	Archive(key Key, metadata []byte) error
+}
+
+// ArchiverFunc is an Archiver calling the function.
+type ArchiverFunc func(key Key, metadata []byte) error
+
+// Archive implement Archiver.
+func (f ArchiverFunc) Archive(key Key, metadata []byte) error {
+	return f(key, metadata)
+}
+
+// DirArchiver return an Archiver writing the metadata of each image to the
+// file of dir named after the key, like "9f86...0f00.meta".
+func DirArchiver(dir string) Archiver {
+	return ArchiverFunc(func(key Key, metadata []byte) error {
+		return os.WriteFile(filepath.Join(dir, key.String()+".meta"), metadata, 0o644)
+	})
+}
+
+// WriterArchiver return an Archiver appending the metadata of each image to
+// w as a line holding the key in hex and the size of the metadata, followed
+// by the metadata.
+func WriterArchiver(w io.Writer) Archiver {
+	var mu sync.Mutex
+	return ArchiverFunc(func(key Key, metadata []byte) (err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		if _, err = fmt.Fprintf(w, "%s %d\n", key, len(metadata)); err != nil {
+			return
+		}
+		_, err = w.Write(metadata)
+		return
+	})
+}
+
+// archive pass the segments of in removed by edits to the archiver of p,
+// keyed by the hash of the output the edits produce.
+func (p *Processor) archive(in []byte, edits []edit) (err error) {
+	var (
+		h        = sha256.New()
+		metadata = []byte{0xff, 0xd8}
+		last     = 0
+	)
+	for _, e := range edits {
+		h.Write(in[last:e.seg.offset])
+		h.Write(e.repl)
+		last = e.seg.offset + e.seg.size
+		if !p.opts.RelocateXMP || e.seg.size == 0 || !isXMP(in, e.seg) {
+			metadata = append(metadata, in[e.seg.offset:last]...)
+		}
+	}
+	h.Write(in[last:])
+	if len(metadata) == 2 {
+		return
+	}
+	var k Key
+	h.Sum(k[:0])
+	callback(func() { err = p.opts.Archive.Archive(k, append(metadata, 0xff, 0xd9)) })
+	return
+}
@@ -0,0 +1,61 @@
+package exif
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestArchive(t *testing.T) {
+	src := readFixture(t, "jfif_bigEndian.jpg")
+	var (
+		key      Key
+		metadata []byte
+	)
+	p := NewProcessor(Options{
+		Handlers: []SegmentHandler{{Marker: 0xed, Prefix: photoshopPrefix, Handle: func([]byte) (SegmentAction, []byte) {
+			return DropSegment, nil
+		}}},
+		Archive: ArchiverFunc(func(k Key, b []byte) error {
+			key, metadata = k, b
+			return nil
+		}),
+	})
+	out, err := p.Process(src)
+	if err != nil {
+		t.Fatalf("Process error(%v)", err)
+	}
+	if key != sha256.Sum256(out) {
+		t.Fatalf("Archive key %v, want the hash of the output", key)
+	}
+	var kinds []string
+	infos, err := Segments(metadata)
+	for _, s := range infos {
+		kinds = append(kinds, s.Kind)
+	}
+	if err != nil || fmt.Sprint(kinds) != "[exif APP13]" {
+		t.Fatalf("archived segments %v error(%v), want exif and APP13", kinds, err)
+	}
+	want, _ := exifBlock(src)
+	if got, err := exifBlock(metadata); err != nil || !bytes.Equal(got, want) {
+		t.Fatalf("exifBlock(archive) error(%v), want the exif of the input", err)
+	}
+
+	var buf bytes.Buffer
+	p = NewProcessor(Options{Archive: WriterArchiver(&buf)})
+	if out, err = p.Process(src); err != nil {
+		t.Fatalf("Process error(%v)", err)
+	}
+	line, rest, _ := bytes.Cut(buf.Bytes(), []byte("\n"))
+	if want := fmt.Sprintf("%x %d", sha256.Sum256(out), len(rest)); string(line) != want || !bytes.HasPrefix(rest, []byte{0xff, 0xd8}) {
+		t.Fatalf("WriterArchiver wrote %q, want %q and the metadata", line, want)
+	}
+
+	fail := errors.New("archive down")
+	p = NewProcessor(Options{Archive: ArchiverFunc(func(Key, []byte) error { return fail })})
+	if out, err = p.Process(src); !errors.Is(err, fail) || out != nil {
+		t.Fatalf("Process error(%v), want the archive error and no output", err)
+	}
+}
@@ -0,0 +1,213 @@
+package exif
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"sync"
+	"time"
+)
+
+// Fetcher read the images of a batch, like an S3, GCS or Azure client.
+type Fetcher interface {
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+}
+
+// Putter write the processed images of a batch.
+type Putter interface {
+	Put(ctx context.Context, key string, r io.Reader) error
+}
+
+// Batch remove the exif of remote images, fetching them with Fetcher and
+// putting the results with Putter under the same key.
+// The panics of Fetcher, Putter and the callbacks of Processor are not
+// recovered, they crash the program like in any goroutine of the caller.
+type Batch struct {
+	// Processor process the images, NewProcessor(Options{}) if nil.
+	// Images without exif are put unchanged.
+	Processor *Processor
+	Fetcher   Fetcher
+	Putter    Putter
+	// Concurrency is the number of images processed at once, 1 if 0.
+	Concurrency int
+	// Retries is the number of times a failed Get or Put is retried.
+	Retries int
+	// Backoff is the delay before the first retry, doubled for each next one.
+	Backoff time.Duration
+	// MaxFailureRate is the fraction of keys, like 0.1, which may fail
+	// before Run stop starting new images, 0 never stop early.
+	MaxFailureRate float64
+}
+
+// ErrTooManyFailures is the BatchError.Err of a batch aborted by MaxFailureRate.
+var ErrTooManyFailures = errors.New("too many failures")
+
+// KeyError is the error of the image of Key.
+type KeyError struct {
+	Key string
+	Err error
+}
+
+// Error implement error.
+func (e *KeyError) Error() string {
+	return e.Key + ": " + e.Err.Error()
+}
+
+// Unwrap return the error of the image.
+func (e *KeyError) Unwrap() error {
+	return e.Err
+}
+
+// BatchError is the error of a batch, it unwrap to all its errors like
+// errors.Join so errors.Is and errors.As match any of them.
+type BatchError struct {
+	Keys []*KeyError // failed images, in the order they failed
+	// Err stopped the batch before all keys were started, the context error
+	// or ErrTooManyFailures, nil if all were.
+	Err error
+}
+
+// Error implement error, the errors are joined with newlines like errors.Join.
+func (e *BatchError) Error() string {
+	return errors.Join(e.Unwrap()...).Error()
+}
+
+// Unwrap return the errors of the images followed by Err.
+func (e *BatchError) Unwrap() []error {
+	errs := make([]error, 0, len(e.Keys)+1)
+	for _, k := range e.Keys {
+		errs = append(errs, k)
+	}
+	if e.Err != nil {
+		errs = append(errs, e.Err)
+	}
+	return errs
+}
+
+// Run process the images of keys, it return a *BatchError holding the
+// errors of the images which failed after processing all others, unless
+// MaxFailureRate is exceeded.
+func (b *Batch) Run(ctx context.Context, keys []string) error {
+	p := b.Processor
+	if p == nil {
+		p = NewProcessor(Options{})
+	}
+	n := b.Concurrency
+	if n <= 0 {
+		n = 1
+	}
+	var (
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+		be      BatchError
+		aborted bool
+		work    = make(chan string)
+	)
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for key := range work {
+				if err := b.one(ctx, p, key); err != nil {
+					mu.Lock()
+					be.Keys = append(be.Keys, &KeyError{Key: key, Err: err})
+					aborted = b.MaxFailureRate > 0 && float64(len(be.Keys)) > b.MaxFailureRate*float64(len(keys))
+					mu.Unlock()
+				}
+			}
+		}()
+	}
+	for _, key := range keys {
+		mu.Lock()
+		stop := aborted
+		mu.Unlock()
+		if be.Err = ctx.Err(); be.Err != nil {
+			break
+		}
+		if stop {
+			be.Err = ErrTooManyFailures
+			break
+		}
+		work <- key
+	}
+	close(work)
+	wg.Wait()
+	if be.Err == nil {
+		be.Err = ctx.Err()
+	}
+	if len(be.Keys) == 0 && be.Err == nil {
+		return nil
+	}
+	return &be
+}
+
+// one process the image of key.
+// It does not recover panics, p already does for the image and those of
+// Fetcher and Putter are bugs of the caller.
+func (b *Batch) one(ctx context.Context, p *Processor, key string) (err error) {
+	var (
+		in       []byte
+		streamed bool
+	)
+	err = b.retry(ctx, func() (err error) {
+		var rc io.ReadCloser
+		if rc, err = b.Fetcher.Get(ctx, key); err != nil {
+			return
+		}
+		defer rc.Close()
+		if in, err = readImage(rc); err == ErrImageTooLarge {
+			// a failed put is retried with the image fetched again
+			streamed = true
+			err = b.stream(ctx, p, key, io.MultiReader(bytes.NewReader(in), rc))
+		}
+		return
+	})
+	if err != nil || streamed {
+		return
+	}
+	out, err := p.Process(in)
+	if err == ErrNoExif {
+		out, err = in, nil
+	}
+	if err != nil {
+		return
+	}
+	return b.retry(ctx, func() error {
+		return b.Putter.Put(ctx, key, bytes.NewReader(out))
+	})
+}
+
+// stream put the image read from r through a Stream, for the images larger
+// than MaxImageSize.
+func (b *Batch) stream(ctx context.Context, p *Processor, key string, r io.Reader) (err error) {
+	pr, pw := io.Pipe()
+	done := make(chan error, 1)
+	go func() {
+		_, serr := p.NewStream(pw).ReadFrom(r)
+		pw.CloseWithError(serr)
+		done <- serr
+	}()
+	err = b.Putter.Put(ctx, key, pr)
+	pr.Close() // unblock the Stream if Put did not read all of it
+	if serr := <-done; serr != nil && serr != io.ErrClosedPipe {
+		err = serr
+	}
+	return
+}
+
+// retry call fn until it succeed, up to Retries more times.
+func (b *Batch) retry(ctx context.Context, fn func() error) (err error) {
+	delay := b.Backoff
+	for i := 0; ; i++ {
+		if err = fn(); err == nil || i >= b.Retries || ctx.Err() != nil {
+			return
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(delay):
+		}
+		delay *= 2
+	}
+}
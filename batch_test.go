@@ -0,0 +1,109 @@
+package exif
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"sync"
+	"testing"
+)
+
+// memStore is an in-memory Fetcher and Putter failing the first Get of each key.
+type memStore struct {
+	mu     sync.Mutex
+	in     map[string][]byte
+	out    map[string][]byte
+	failed map[string]bool
+}
+
+func (s *memStore) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.failed[key] {
+		s.failed[key] = true
+		return nil, errors.New("transient")
+	}
+	b, ok := s.in[key]
+	if !ok {
+		return nil, errors.New("not found")
+	}
+	return io.NopCloser(bytes.NewReader(b)), nil
+}
+
+func (s *memStore) Put(ctx context.Context, key string, r io.Reader) error {
+	b, err := io.ReadAll(r)
+	s.mu.Lock()
+	s.out[key] = b
+	s.mu.Unlock()
+	return err
+}
+
+func TestBatch(t *testing.T) {
+	src := readFixture(t, filename)
+	none, _ := StripAll(src)
+	s := &memStore{
+		in:     map[string][]byte{"a.jpg": src, "b.jpg": none, "c.jpg": src},
+		out:    make(map[string][]byte),
+		failed: make(map[string]bool),
+	}
+	b := &Batch{Fetcher: s, Putter: s, Concurrency: 2, Retries: 1}
+	err := b.Run(context.Background(), []string{"a.jpg", "b.jpg", "c.jpg", "missing.jpg"})
+	if err == nil || !bytes.Contains([]byte(err.Error()), []byte("missing.jpg: not found")) {
+		t.Fatalf("Run error(%v), want missing.jpg to fail", err)
+	}
+	want, _ := StripAll(src)
+	if len(s.out) != 3 || !bytes.Equal(s.out["a.jpg"], want) || !bytes.Equal(s.out["b.jpg"], none) {
+		t.Fatalf("Run put %d images, want 3 stripped", len(s.out))
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err = b.Run(ctx, []string{"a.jpg"}); !errors.Is(err, context.Canceled) {
+		t.Fatalf("Run(canceled) error(%v)", err)
+	}
+}
+
+func TestBatchFailureRate(t *testing.T) {
+	src := readFixture(t, filename)
+	s := &memStore{in: make(map[string][]byte), out: make(map[string][]byte), failed: make(map[string]bool)}
+	var keys []string
+	for _, k := range "abcdefgh" {
+		keys = append(keys, string(k)+".jpg")
+		s.in[string(k)+".jpg"] = src
+	}
+	// without retries the first Get of each key fail
+	b := &Batch{Fetcher: s, Putter: s, MaxFailureRate: 0.25}
+	err := b.Run(context.Background(), keys)
+	var be *BatchError
+	if !errors.As(err, &be) || !errors.Is(err, ErrTooManyFailures) {
+		t.Fatalf("Run error(%v), want ErrTooManyFailures", err)
+	}
+	if len(be.Keys) < 3 || len(be.Keys) == len(keys) || be.Keys[0].Key != "a.jpg" {
+		t.Fatalf("Run failed %d keys, want the batch aborted after 3", len(be.Keys))
+	}
+	var ke *KeyError
+	if !errors.As(err, &ke) || ke.Err.Error() != "transient" {
+		t.Fatalf("Run error(%v), want a KeyError", err)
+	}
+	b.MaxFailureRate, b.Retries = 0, 1
+	if err = b.Run(context.Background(), keys); err != nil {
+		t.Fatalf("Run(retried gets) error(%v)", err)
+	}
+}
+
+func TestBatchLargeImage(t *testing.T) {
+	src := readFixture(t, filename)
+	defer func(max int64) { MaxImageSize = max }(MaxImageSize)
+	MaxImageSize = int64(len(src)) / 2
+	if _, err := StripReader(bytes.NewReader(src)); err != ErrImageTooLarge {
+		t.Fatalf("StripReader error(%v), want ErrImageTooLarge", err)
+	}
+	s := &memStore{in: map[string][]byte{"a.jpg": src}, out: make(map[string][]byte), failed: make(map[string]bool)}
+	b := &Batch{Fetcher: s, Putter: s, Retries: 1}
+	if err := b.Run(context.Background(), []string{"a.jpg"}); err != nil {
+		t.Fatalf("Run error(%v)", err)
+	}
+	if want, _ := StripAll(src); !bytes.Equal(s.out["a.jpg"], want) {
+		t.Fatalf("Run put %d bytes, want the streamed image", len(s.out["a.jpg"]))
+	}
+}
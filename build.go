@@ -0,0 +1,164 @@
+package exif
+
+import (
+	"encoding/binary"
+	"image"
+	"math"
+	"time"
+)
+
+// tag ids written by Build
+const (
+	xResolutionTag             = 0x011a
+	yResolutionTag             = 0x011b
+	resolutionUnitTag          = 0x0128
+	softwareTag                = 0x0131
+	dateTimeTag                = 0x0132
+	yCbCrPositioningTag        = 0x0213
+	exifVersionTag             = 0x9000
+	dateTimeOriginalTag        = 0x9003
+	offsetTimeTag              = 0x9010
+	offsetTimeOriginalTag      = 0x9011
+	componentsConfigurationTag = 0x9101
+	flashpixVersionTag         = 0xa000
+	colorSpaceTag              = 0xa001
+	gpsVersionIDTag            = 0x0000
+	gpsLatitudeRefTag          = 0x0001
+	gpsLatitudeTag             = 0x0002
+	gpsLongitudeRefTag         = 0x0003
+	gpsLongitudeTag            = 0x0004
+	gpsTimeStampTag            = 0x0007
+	gpsDateStampTag            = 0x001d
+)
+
+// exif date time layouts
+const (
+	dateTimeLayout   = "2006:01:02 15:04:05"
+	offsetTimeLayout = "-07:00"
+	gpsDateLayout    = "2006:01:02"
+)
+
+// Params is the metadata Build write besides the image dimensions.
+type Params struct {
+	// Orientation is the exif orientation from 1 to 8, 0 means 1 (top-left).
+	Orientation int
+	// DateTime is written as DateTime and DateTimeOriginal with its time
+	// zone offset, it is omitted if zero.
+	DateTime time.Time
+	// Software is the name of the generating software, omitted if empty.
+	Software string
+	// GPS is the location, omitted if nil.
+	GPS *GPS
+	// ByteOrder of the tiff block, binary.BigEndian if nil.
+	ByteOrder binary.ByteOrder
+}
+
+// GPS is a location in decimal degrees, negative for south and west.
+type GPS struct {
+	Latitude  float64
+	Longitude float64
+	// Time is the time of the fix, written in UTC as GPSTimeStamp and
+	// GPSDateStamp, they are omitted if zero.
+	Time time.Time
+}
+
+// Build return a minimal exif APP1 payload for a programmatically generated
+// image of config c, insert it into a jpeg with
+// InsertSegment(in, 0xe1, payload, AfterAPP0).
+func Build(c image.Config, p Params) (payload []byte, err error) {
+	defer recoverCorrupt(&err)
+	if p.Orientation == 0 {
+		p.Orientation = 1
+	}
+	if p.Orientation < 1 || p.Orientation > 8 || c.Width < 0 || c.Height < 0 {
+		err = ErrInvalidTagValue
+		return
+	}
+	t := &tiff{order: binary.BigEndian}
+	if p.ByteOrder == binary.LittleEndian {
+		t.order = binary.LittleEndian
+	}
+	t.ifd0 = []entry{
+		t.shorts(orientationTag, uint16(p.Orientation)),
+		t.rationals(xResolutionTag, 72, 1),
+		t.rationals(yResolutionTag, 72, 1),
+		t.shorts(resolutionUnitTag, 2),
+		t.shorts(yCbCrPositioningTag, 1),
+	}
+	t.exif = []entry{
+		t.undefined(exifVersionTag, []byte("0232")),
+		t.undefined(componentsConfigurationTag, []byte{1, 2, 3, 0}),
+		t.undefined(flashpixVersionTag, []byte("0100")),
+		t.shorts(colorSpaceTag, 1),
+		t.longs(pixelXDimensionTag, uint32(c.Width)),
+		t.longs(pixelYDimensionTag, uint32(c.Height)),
+	}
+	if p.Software != "" {
+		t.ifd0 = append(t.ifd0, t.ascii(softwareTag, p.Software))
+	}
+	if !p.DateTime.IsZero() {
+		dt, offset := p.DateTime.Format(dateTimeLayout), p.DateTime.Format(offsetTimeLayout)
+		t.ifd0 = append(t.ifd0, t.ascii(dateTimeTag, dt))
+		t.exif = append(t.exif,
+			t.ascii(dateTimeOriginalTag, dt),
+			t.ascii(offsetTimeTag, offset),
+			t.ascii(offsetTimeOriginalTag, offset),
+		)
+	}
+	if p.GPS != nil {
+		lat, lon := p.GPS.Latitude, p.GPS.Longitude
+		if math.IsNaN(lat) || math.IsNaN(lon) || math.IsInf(lat, 0) || math.IsInf(lon, 0) ||
+			math.Abs(lat) > 90 || math.Abs(lon) > 180 {
+			err = ErrInvalidTagValue
+			return
+		}
+		t.gps = t.location(*p.GPS)
+	}
+	payload, _, err = t.app1()
+	return
+}
+
+// location return the gps IFD entries of g.
+func (t *tiff) location(g GPS) []entry {
+	latRef, lonRef := "N", "E"
+	if g.Latitude < 0 {
+		latRef = "S"
+	}
+	if g.Longitude < 0 {
+		lonRef = "W"
+	}
+	es := []entry{
+		t.bytes(gpsVersionIDTag, 2, 3, 0, 0),
+		t.ascii(gpsLatitudeRefTag, latRef),
+		t.rationals(gpsLatitudeTag, dms(g.Latitude)...),
+		t.ascii(gpsLongitudeRefTag, lonRef),
+		t.rationals(gpsLongitudeTag, dms(g.Longitude)...),
+	}
+	if !g.Time.IsZero() {
+		utc := g.Time.UTC()
+		es = append(es,
+			t.rationals(gpsTimeStampTag, hms(utc)...),
+			t.ascii(gpsDateStampTag, utc.Format(gpsDateLayout)),
+		)
+	}
+	return es
+}
+
+// hms return the hour, minute and second rationals of u, with the seconds in milliseconds.
+func hms(u time.Time) []uint32 {
+	return []uint32{
+		uint32(u.Hour()), 1,
+		uint32(u.Minute()), 1,
+		uint32(u.Second()*1000 + u.Nanosecond()/1e6), 1000,
+	}
+}
+
+// dms return the degrees, minutes and seconds rationals of the absolute value of deg.
+func dms(deg float64) []uint32 {
+	ms := uint64(math.Round(math.Abs(deg) * 3600 * 1000)) // milliseconds of arc
+	return []uint32{
+		uint32(ms / 3600000), 1,
+		uint32(ms % 3600000 / 60000), 1,
+		uint32(ms % 60000), 1000,
+	}
+}
@@ -0,0 +1,84 @@
+package exif
+
+import (
+	"encoding/binary"
+	"image"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestBuild(t *testing.T) {
+	src, err := StripAll(readFixture(t, filename))
+	if err != nil {
+		t.Fatalf("StripAll error(%v)", err)
+	}
+	when := time.Date(2020, 3, 8, 10, 4, 5, 0, time.FixedZone("", 8*3600))
+	for _, order := range []binary.ByteOrder{binary.BigEndian, binary.LittleEndian} {
+		payload, err := Build(image.Config{Width: 640, Height: 480}, Params{
+			Orientation: 6,
+			DateTime:    when,
+			Software:    "render",
+			GPS:         &GPS{Latitude: 38.889750, Longitude: -77.0089},
+			ByteOrder:   order,
+		})
+		if err != nil {
+			t.Fatalf("Build error(%v)", err)
+		}
+		out, err := InsertSegment(src, 0xe1, payload, AfterAPP0)
+		if err != nil {
+			t.Fatalf("InsertSegment error(%v)", err)
+		}
+		b, err := exifBlock(out)
+		if err != nil {
+			t.Fatalf("exifBlock error(%v)", err)
+		}
+		tf, err := parseTIFF(b)
+		if err != nil {
+			t.Fatalf("parseTIFF error(%v)", err)
+		}
+		if tf.order != order {
+			t.Fatalf("byte order = %v, want %v", tf.order, order)
+		}
+		if v, _ := tf.uint(tf.ifd0, orientationTag); v != 6 {
+			t.Fatalf("orientation = %d, want 6", v)
+		}
+		if v, _ := tf.uint(tf.exif, pixelXDimensionTag); v != 640 {
+			t.Fatalf("PixelXDimension = %d, want 640", v)
+		}
+		if e, _ := lookup(tf.ifd0, dateTimeTag); string(e.value) != "2020:03:08 10:04:05\x00" {
+			t.Fatalf("DateTime = %q", e.value)
+		}
+		if e, _ := lookup(tf.exif, offsetTimeTag); string(e.value) != "+08:00\x00" {
+			t.Fatalf("OffsetTime = %q", e.value)
+		}
+		if e, _ := lookup(tf.gps, gpsLongitudeRefTag); string(e.value) != "W\x00" {
+			t.Fatalf("GPSLongitudeRef = %q", e.value)
+		}
+		e, _ := lookup(tf.gps, gpsLatitudeTag)
+		var lat float64
+		for i, unit := range []float64{1, 60, 3600} {
+			lat += float64(order.Uint32(e.value[i*8:])) / float64(order.Uint32(e.value[i*8+4:])) / unit
+		}
+		if math.Abs(lat-38.889750) > 1e-6 {
+			t.Fatalf("GPSLatitude = %v, want 38.889750", lat)
+		}
+		if out, err = Strip(out); err != nil {
+			t.Fatalf("Strip error(%v)", err)
+		}
+	}
+	if _, err = Build(image.Config{}, Params{Orientation: 9}); err != ErrInvalidTagValue {
+		t.Fatalf("Build(orientation 9) error(%v), want %v", err, ErrInvalidTagValue)
+	}
+	for _, g := range []GPS{
+		{Latitude: math.NaN()},
+		{Longitude: math.NaN()},
+		{Latitude: math.Inf(1)},
+		{Longitude: math.Inf(-1)},
+		{Latitude: 91},
+	} {
+		if _, err = Build(image.Config{}, Params{GPS: &g}); err != ErrInvalidTagValue {
+			t.Fatalf("Build(%v) error(%v), want %v", g, err, ErrInvalidTagValue)
+		}
+	}
+}
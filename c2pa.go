@@ -0,0 +1,156 @@
+package exif
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+)
+
+// markerAPP11 is the marker of APP11, which hold JPEG XT JUMBF boxes.
+const markerAPP11 = 0xffeb
+
+// c2paLabel is the label and the first four bytes of the type of C2PA manifest stores.
+var c2paLabel = []byte("c2pa")
+
+// jumbf is an APP11 segment holding (part of) a JUMBF box.
+type jumbf struct {
+	instance uint16 // box instance number, shared by all segments of a box
+	sequence uint32 // packet sequence number, starting from 1
+	box      []byte // box data, each segment repeat the box header
+}
+
+// parseJUMBF parse the JPEG XT header of an APP11 payload.
+func parseJUMBF(p []byte) (j jumbf, ok bool) {
+	if len(p) < 16 || p[0] != 'J' || p[1] != 'P' {
+		return
+	}
+	j = jumbf{
+		instance: binary.BigEndian.Uint16(p[2:]),
+		sequence: binary.BigEndian.Uint32(p[4:]),
+		box:      p[8:],
+	}
+	ok = string(j.box[4:8]) == "jumb"
+	return
+}
+
+// description return the type and the label of the superbox description
+// box, which is only present in the first segment of a box.
+func (j jumbf) description() (typ []byte, label string, ok bool) {
+	hdr := 8
+	if binary.BigEndian.Uint32(j.box) == 1 { // extended box length
+		hdr = 16
+	}
+	if len(j.box) < hdr {
+		return
+	}
+	d := j.box[hdr:]
+	if len(d) < 8+16+1 || string(d[4:8]) != "jumd" {
+		return
+	}
+	typ, d = d[8:24], d[25:]
+	if i := bytes.IndexByte(d, 0); i >= 0 {
+		d = d[:i]
+	}
+	label, ok = string(d), true
+	return
+}
+
+// isC2PA report whether j is the first segment of a C2PA manifest store.
+func (j jumbf) isC2PA() bool {
+	typ, label, ok := j.description()
+	return ok && (bytes.HasPrefix(typ, c2paLabel) || label == string(c2paLabel))
+}
+
+// c2paBoxes return the instance numbers of the C2PA JUMBF boxes in segs.
+func c2paBoxes(in []byte, segs []segment) (boxes map[uint16]bool) {
+	for _, s := range segs {
+		if s.marker != markerAPP11 {
+			continue
+		}
+		j, ok := parseJUMBF(s.payload(in))
+		if !ok {
+			continue
+		}
+		if j.isC2PA() {
+			if boxes == nil {
+				boxes = make(map[uint16]bool)
+			}
+			boxes[j.instance] = true
+		}
+	}
+	return
+}
+
+// inBoxes report whether the APP11 payload p is a segment of one of boxes.
+func inBoxes(boxes map[uint16]bool, p []byte) bool {
+	j, ok := parseJUMBF(p)
+	return ok && boxes[j.instance]
+}
+
+// HasC2PA report whether image contain a C2PA (Content Credentials) manifest.
+func HasC2PA(in []byte) (ok bool, err error) {
+	defer recoverCorrupt(&err)
+	var segs []segment
+	if segs, _, err = readSegments(in); err != nil {
+		return
+	}
+	ok = len(c2paBoxes(in, segs)) > 0
+	return
+}
+
+// StripC2PA remove the C2PA manifest segments, all other bytes are left untouched.
+// Strip and StripAll never remove C2PA data, stripping it must be asked explicitly.
+func StripC2PA(in []byte) (out []byte, err error) {
+	defer recoverCorrupt(&err)
+	var segs []segment
+	if segs, _, err = readSegments(in); err != nil {
+		return
+	}
+	boxes := c2paBoxes(in, segs)
+	out = make([]byte, 0, len(in))
+	last := 0
+	for _, s := range segs {
+		if s.marker == markerAPP11 && inBoxes(boxes, s.payload(in)) {
+			out = append(out, in[last:s.offset]...)
+			last = s.offset + s.size
+		}
+	}
+	out = append(out, in[last:]...)
+	return
+}
+
+// JUMBF describe the JUMBF box carried by an APP11 segment.
+type JUMBF struct {
+	Instance uint16 // box instance number, shared by all segments of a box
+	Sequence uint32 // packet sequence number, starting from 1
+	// Type is the content type UUID of the superbox and Label its label,
+	// they are only set for the first segment of a box.
+	Type  [16]byte
+	Label string
+	C2PA  bool // the box is a C2PA manifest store
+}
+
+// TypeName return the content type of j as its leading four characters
+// code, like "c2pa" or "json", or as a UUID if they are not printable.
+func (j JUMBF) TypeName() string {
+	if isPrintable(j.Type[:4]) && j.Type[3] != 0 {
+		return string(j.Type[:4])
+	}
+	t := j.Type
+	return fmt.Sprintf("%x-%x-%x-%x-%x", t[0:4], t[4:6], t[6:8], t[8:10], t[10:])
+}
+
+// jumbfInfo return the description of the APP11 payload p, ok is false if p
+// is not a JUMBF box.
+func jumbfInfo(p []byte, boxes map[uint16]bool) (info JUMBF, ok bool) {
+	j, ok := parseJUMBF(p)
+	if !ok {
+		return
+	}
+	info = JUMBF{Instance: j.instance, Sequence: j.sequence, C2PA: boxes[j.instance]}
+	if typ, label, dok := j.description(); dok {
+		copy(info.Type[:], typ)
+		info.Label = label
+	}
+	return
+}
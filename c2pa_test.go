@@ -0,0 +1,93 @@
+package exif
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+)
+
+// testJUMBF build an APP11 payload holding the first segment of a JUMBF box.
+func testJUMBF(instance uint16, typ, label string) []byte {
+	jumd := make([]byte, 8, 64)
+	jumd = append(jumd, typ...)
+	jumd = append(jumd, make([]byte, 16-len(typ))...)
+	jumd = append(jumd, 0x03)
+	jumd = append(jumd, label...)
+	jumd = append(jumd, 0)
+	binary.BigEndian.PutUint32(jumd, uint32(len(jumd)))
+	copy(jumd[4:], "jumd")
+	p := []byte{'J', 'P', 0, 0, 0, 0, 0, 1, 0, 0, 0, 0, 'j', 'u', 'm', 'b'}
+	binary.BigEndian.PutUint16(p[2:], instance)
+	binary.BigEndian.PutUint32(p[8:], uint32(8+len(jumd)))
+	return append(p, jumd...)
+}
+
+func TestC2PA(t *testing.T) {
+	src := readFixture(t, filename)
+	if ok, err := HasC2PA(src); err != nil || ok {
+		t.Fatalf("HasC2PA(%s) = %v, %v, want false", filename, ok, err)
+	}
+	other, err := InsertSegment(src, 0xeb, testJUMBF(1, "jpxt", "other"), AfterAPPn)
+	if err != nil {
+		t.Fatalf("InsertSegment error(%v)", err)
+	}
+	signed, err := InsertSegment(other, 0xeb, testJUMBF(2, "c2pa", "c2pa"), AfterAPPn)
+	if err != nil {
+		t.Fatalf("InsertSegment error(%v)", err)
+	}
+	if ok, err := HasC2PA(signed); err != nil || !ok {
+		t.Fatalf("HasC2PA = %v, %v, want true", ok, err)
+	}
+	manifest := append([]byte{0xff, 0xeb, 0, 0}, testJUMBF(2, "c2pa", "c2pa")...)
+	binary.BigEndian.PutUint16(manifest[2:], uint16(len(manifest)-2))
+	for name, strip := range map[string]func([]byte) ([]byte, error){"Strip": Strip, "StripAll": StripAll} {
+		out, err := strip(signed)
+		if err != nil {
+			t.Fatalf("%s error(%v)", name, err)
+		}
+		if !bytes.Contains(out, manifest) {
+			t.Fatalf("%s did not keep the C2PA manifest bit-exact", name)
+		}
+	}
+	out, err := StripC2PA(signed)
+	if err != nil {
+		t.Fatalf("StripC2PA error(%v)", err)
+	}
+	if !bytes.Equal(out, other) {
+		t.Fatalf("StripC2PA did not remove only the C2PA manifest")
+	}
+}
+
+func TestSegmentsJUMBF(t *testing.T) {
+	src := readFixture(t, filename)
+	uuid := "\x12\x34\x56\x78\x9a\xbc\xde\xf0\x11\x22\x33\x44\x55\x66\x77\x88"
+	in, err := InsertSegment(src, 0xeb, testJUMBF(1, uuid, "meta"), AfterAPPn)
+	if err != nil {
+		t.Fatalf("InsertSegment error(%v)", err)
+	}
+	if in, err = InsertSegment(in, 0xeb, testJUMBF(2, "c2pa", "c2pa"), AfterAPPn); err != nil {
+		t.Fatalf("InsertSegment error(%v)", err)
+	}
+	infos, err := Segments(in)
+	if err != nil {
+		t.Fatalf("Segments error(%v)", err)
+	}
+	var boxes []JUMBF
+	for _, s := range infos {
+		if s.Marker == markerAPP11 && s.JUMBF != nil {
+			boxes = append(boxes, *s.JUMBF)
+		}
+		if got := in[s.Offset+1]; uint16(0xff00|uint16(got)) != s.Marker {
+			t.Fatalf("segment at %d has marker %#x, want %#x", s.Offset, got, s.Marker)
+		}
+	}
+	if len(boxes) != 2 || infos[0].Kind != "exif" || infos[1].Kind != "APP11" || infos[2].Kind != "c2pa" {
+		t.Fatalf("Segments = %+v", infos)
+	}
+	if b := boxes[0]; b.Instance != 1 || b.Sequence != 1 || b.Label != "meta" || b.C2PA || b.TypeName() != "12345678-9abc-def0-1122-334455667788" {
+		t.Fatalf("box = %+v", b)
+	}
+	if b := boxes[1]; !b.C2PA || b.TypeName() != "c2pa" || b.Label != "c2pa" {
+		t.Fatalf("box = %+v, %s", b, b.TypeName())
+	}
+}
@@ -0,0 +1,120 @@
+package exif
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"sync"
+)
+
+// Key identify an image in a Cache, like the SHA-256 of its content the
+// caller computed on upload, so identical uploads share it.
+type Key [sha256.Size]byte
+
+// String return the key in hex.
+func (k Key) String() string {
+	return hex.EncodeToString(k[:])
+}
+
+// Cache remember whether images have exif, so re-shared images are not
+// parsed again. It must be safe for concurrent use.
+type Cache interface {
+	// Get return whether the image of k has exif, ok is false if k is unknown.
+	Get(k Key) (has, ok bool)
+	// Put remember whether the image of k has exif.
+	Put(k Key, has bool)
+}
+
+// LRU is an in-memory Cache of a fixed number of images, evicting the
+// least recently used first.
+type LRU struct {
+	mu    sync.Mutex
+	size  int
+	order *list.List // *lruEntry, most recently used first
+	items map[Key]*list.Element
+}
+
+// lruEntry is an element of LRU.order.
+type lruEntry struct {
+	k   Key
+	has bool
+}
+
+// NewLRU return a LRU of size images, at least 1.
+func NewLRU(size int) *LRU {
+	if size < 1 {
+		size = 1
+	}
+	return &LRU{size: size, order: list.New(), items: make(map[Key]*list.Element)}
+}
+
+// Get implement Cache.
+func (c *LRU) Get(k Key) (has, ok bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	el, ok := c.items[k]
+	if !ok {
+		return
+	}
+	c.order.MoveToFront(el)
+	has = el.Value.(*lruEntry).has
+	return
+}
+
+// Put implement Cache.
+func (c *LRU) Put(k Key, has bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if el, ok := c.items[k]; ok {
+		el.Value.(*lruEntry).has = has
+		c.order.MoveToFront(el)
+		return
+	}
+	c.items[k] = c.order.PushFront(&lruEntry{k: k, has: has})
+	if c.order.Len() > c.size {
+		el := c.order.Back()
+		c.order.Remove(el)
+		delete(c.items, el.Value.(*lruEntry).k)
+	}
+}
+
+// Len return the number of images in c.
+func (c *LRU) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.order.Len()
+}
+
+// HasExif report whether the jpeg image in has an exif APP1 segment.
+func HasExif(in []byte) (has bool, err error) {
+	defer recoverCorrupt(&err)
+	var segs []segment
+	if segs, _, err = readSegments(in); err != nil {
+		return
+	}
+	for _, s := range segs {
+		if isExif(in, s) {
+			has = true
+			return
+		}
+	}
+	return
+}
+
+// HasExifKey is HasExif consulting the Cache of p under k, a key of in the
+// caller already has.
+func (p *Processor) HasExifKey(in []byte, k Key) (has bool, err error) {
+	defer recoverCorrupt(&err)
+	c := p.opts.Cache
+	if c == nil {
+		return HasExif(in)
+	}
+	var ok bool
+	if callback(func() { has, ok = c.Get(k) }); ok {
+		return
+	}
+	if has, err = HasExif(in); err == nil {
+		callback(func() { c.Put(k, has) })
+	}
+	return
+}
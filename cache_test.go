@@ -0,0 +1,105 @@
+package exif
+
+import (
+	"crypto/sha256"
+	"os"
+	"testing"
+)
+
+func TestLRU(t *testing.T) {
+	c := NewLRU(2)
+	a, b, d := Key{1}, Key{2}, Key{3}
+	c.Put(a, true)
+	c.Put(b, false)
+	if has, ok := c.Get(a); !ok || !has {
+		t.Fatalf("Get(a) = %v, %v", has, ok)
+	}
+	c.Put(d, true) // evict b, the least recently used
+	if _, ok := c.Get(b); ok {
+		t.Fatalf("b was not evicted")
+	}
+	if _, ok := c.Get(a); !ok {
+		t.Fatalf("a was evicted")
+	}
+	if c.Len() != 2 {
+		t.Fatalf("Len = %d, want 2", c.Len())
+	}
+}
+
+func TestProcessorCache(t *testing.T) {
+	in, err := os.ReadFile("testdata/jfif_bigEndian.jpg")
+	if err != nil {
+		t.Fatalf("ReadFile error(%v)", err)
+	}
+	stripped, err := StripAll(in)
+	if err != nil {
+		t.Fatalf("StripAll error(%v)", err)
+	}
+	c := NewLRU(8)
+	p := NewProcessor(Options{Cache: c})
+	k, sk := Key(sha256.Sum256(in)), Key(sha256.Sum256(stripped))
+	if has, err := p.HasExifKey(in, k); err != nil || !has {
+		t.Fatalf("HasExif = %v, %v", has, err)
+	}
+	if _, err = p.ProcessKey(stripped, sk); err != ErrNoExif {
+		t.Fatalf("ProcessKey error(%v), want ErrNoExif", err)
+	}
+	if has, ok := c.Get(sk); !ok || has {
+		t.Fatalf("cached = %v, %v, want no exif", has, ok)
+	}
+	// a cached answer is trusted without parsing
+	c.Put(k, false)
+	if _, err = p.ProcessKey(in, k); err != ErrNoExif {
+		t.Fatalf("ProcessKey error(%v), want ErrNoExif from the cache", err)
+	}
+	if has, _ := p.HasExifKey(in, k); has {
+		t.Fatalf("HasExifKey did not consult the cache")
+	}
+	// without a key the cache is not consulted
+	if out, err := p.Process(in); err != nil || len(out) >= len(in) {
+		t.Fatalf("Process = %d bytes, %v, want the exif removed", len(out), err)
+	}
+	lenient := NewProcessor(Options{Cache: c, Lenient: true})
+	if out, err := lenient.ProcessKey(in, k); err != nil || len(out) != len(in) {
+		t.Fatalf("lenient Process = %d bytes, %v, want the input", len(out), err)
+	}
+}
+
+func BenchmarkProcessorCache(b *testing.B) {
+	for _, name := range benchFiles {
+		large := readFixture(b, name)
+		noexif, err := StripAll(large)
+		if err != nil {
+			b.Fatalf("StripAll(%s) error(%v)", name, err)
+		}
+		for _, c := range []struct {
+			name string
+			src  []byte
+		}{{"large", large}, {"noexif", noexif}} {
+			k := Key(sha256.Sum256(c.src)) // computed once, as on upload
+			for _, cache := range []Cache{nil, NewLRU(8)} {
+				p := NewProcessor(Options{Cache: cache})
+				mode := "uncached"
+				if cache != nil {
+					mode = "cached"
+				}
+				b.Run(name+"/"+c.name+"/HasExif/"+mode, func(b *testing.B) {
+					b.ReportAllocs()
+					for i := 0; i < b.N; i++ {
+						if _, err := p.HasExifKey(c.src, k); err != nil {
+							b.Fatalf("HasExifKey error(%v)", err)
+						}
+					}
+				})
+				b.Run(name+"/"+c.name+"/Process/"+mode, func(b *testing.B) {
+					b.ReportAllocs()
+					for i := 0; i < b.N; i++ {
+						if _, err := p.ProcessKey(c.src, k); err != nil && err != ErrNoExif {
+							b.Fatalf("ProcessKey error(%v)", err)
+						}
+					}
+				})
+			}
+		}
+	}
+}
@@ -0,0 +1,181 @@
+// Command exifbench run strip and decode workloads over a corpus of jpeg
+// images and report the throughput and allocations, with pprof profiles, to
+// measure the library for high-volume services.
+//
+// Usage:
+//
+//	exifbench -dir corpus [-op strip] [-n 10] [-workers 4] [-cpuprofile cpu.out] [-memprofile mem.out] [-http localhost:6060]
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"net/http"
+	_ "net/http/pprof" // profiles served by -http
+	"os"
+	"path/filepath"
+	"runtime"
+	"runtime/pprof"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/lkzz/exif"
+)
+
+// ops are the workloads, they return the number of bytes written.
+var ops = map[string]func(in []byte) (int, error){
+	"strip": func(in []byte) (int, error) {
+		out, err := exif.Strip(in)
+		return len(out), err
+	},
+	"stripall": func(in []byte) (int, error) {
+		out, err := exif.StripAll(in)
+		return len(out), err
+	},
+	"process": func(in []byte) (int, error) {
+		out, err := processor.Process(in)
+		return len(out), err
+	},
+	"stream": func(in []byte) (int, error) {
+		var n countWriter
+		_, err := processor.NewStream(&n).ReadFrom(bytes.NewReader(in))
+		return int(n), err
+	},
+	"decode": func(in []byte) (int, error) {
+		_, err := exif.Decode(in)
+		return 0, err
+	},
+}
+
+// processor is used by the process and stream workloads.
+var processor = exif.NewProcessor(exif.Options{KeepOrientation: true})
+
+// opNames list the workloads for the usage.
+const opNames = "strip, stripall, process, stream or decode"
+
+// countWriter count the bytes written to it.
+type countWriter int64
+
+func (w *countWriter) Write(p []byte) (int, error) {
+	*w += countWriter(len(p))
+	return len(p), nil
+}
+
+func main() {
+	var (
+		dir        = flag.String("dir", ".", "directory holding the jpeg corpus")
+		op         = flag.String("op", "strip", "workload: "+opNames)
+		n          = flag.Int("n", 10, "passes over the corpus")
+		workers    = flag.Int("workers", runtime.GOMAXPROCS(0), "concurrent workers")
+		cpuprofile = flag.String("cpuprofile", "", "write a cpu profile to this file")
+		memprofile = flag.String("memprofile", "", "write an allocation profile to this file")
+		addr       = flag.String("http", "", "serve net/http/pprof on this address during the run")
+	)
+	flag.Parse()
+	run, ok := ops[*op]
+	if !ok {
+		log.Fatalf("unknown op %q, want one of %s", *op, opNames)
+	}
+	corpus, size, err := load(*dir)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if len(corpus) == 0 {
+		log.Fatalf("no jpeg image in %s", *dir)
+	}
+	if *addr != "" {
+		go func() { log.Println(http.ListenAndServe(*addr, nil)) }()
+	}
+	if *cpuprofile != "" {
+		f, err := os.Create(*cpuprofile)
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer f.Close()
+		if err = pprof.StartCPUProfile(f); err != nil {
+			log.Fatal(err)
+		}
+		defer pprof.StopCPUProfile()
+	}
+
+	var (
+		next, failed, written int64
+		before, after         runtime.MemStats
+		wg                    sync.WaitGroup
+	)
+	total := int64(len(corpus) * *n)
+	runtime.GC()
+	runtime.ReadMemStats(&before)
+	start := time.Now()
+	for i := 0; i < *workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				j := atomic.AddInt64(&next, 1) - 1
+				if j >= total {
+					return
+				}
+				w, err := run(corpus[j%int64(len(corpus))])
+				if err != nil && !errors.Is(err, exif.ErrNoExif) {
+					atomic.AddInt64(&failed, 1)
+				}
+				atomic.AddInt64(&written, int64(w))
+			}
+		}()
+	}
+	wg.Wait()
+	elapsed := time.Since(start)
+	runtime.ReadMemStats(&after)
+
+	read := float64(size) * float64(*n)
+	fmt.Printf("op %s: %d images of %d bytes, %d passes, %d workers\n", *op, len(corpus), size, *n, *workers)
+	fmt.Printf("%v, %.0f images/s, %.1f MB/s read, %.1f MB/s written, %d failed\n",
+		elapsed.Round(time.Millisecond), float64(total)/elapsed.Seconds(),
+		read/elapsed.Seconds()/1e6, float64(written)/elapsed.Seconds()/1e6, failed)
+	fmt.Printf("%.1f allocs/op, %.0f B/op, %d GC\n",
+		float64(after.Mallocs-before.Mallocs)/float64(total),
+		float64(after.TotalAlloc-before.TotalAlloc)/float64(total), after.NumGC-before.NumGC)
+
+	if *memprofile != "" {
+		f, err := os.Create(*memprofile)
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer f.Close()
+		if err = pprof.Lookup("allocs").WriteTo(f, 0); err != nil {
+			log.Fatal(err)
+		}
+	}
+}
+
+// load return the content of the .jpg and .jpeg files of dir and their total size.
+func load(dir string) (corpus [][]byte, size int64, err error) {
+	err = filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		if ext := strings.ToLower(filepath.Ext(path)); ext != ".jpg" && ext != ".jpeg" {
+			return nil
+		}
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		b, err := io.ReadAll(f)
+		if err != nil {
+			return err
+		}
+		corpus, size = append(corpus, b), size+int64(len(b))
+		return nil
+	})
+	return
+}
@@ -0,0 +1,351 @@
+package exif
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Violation is a departure of an exif block from the EXIF 2.32 specification.
+type Violation struct {
+	IFD    string // "ifd0", "exif", "gps", "interop" or "ifd1"
+	Tag    uint16
+	Name   string // tag name, empty for unknown tags
+	Reason string
+}
+
+// String return the violation like "exif ExifVersion (0x9000): missing mandatory tag".
+func (v Violation) String() string {
+	return fmt.Sprintf("%s %s (%#04x): %s", v.IFD, v.Name, v.Tag, v.Reason)
+}
+
+// tagSpec is how the specification define a tag.
+type tagSpec struct {
+	name      string
+	types     uint16 // bit set of the allowed data formats
+	count     uint32 // number of components, 0 for any
+	mandatory bool
+	valid     func(v uint32) bool // allowed values of integer tags, nil for any
+}
+
+// data format bit sets
+const (
+	fB  = 1 << typeByte
+	fA  = 1 << typeASCII
+	fS  = 1 << typeShort
+	fL  = 1 << typeLong
+	fR  = 1 << typeRational
+	fU  = 1 << typeUndefined
+	fSR = 1 << typeSRational
+)
+
+// between return a validator of values in [min, max], or one of more.
+func between(min, max uint32, more ...uint32) func(uint32) bool {
+	return func(v uint32) bool {
+		for _, m := range more {
+			if v == m {
+				return true
+			}
+		}
+		return v >= min && v <= max
+	}
+}
+
+// tiffSpecs are the tiff tags of IFD0 and IFD1 used by exif.
+var tiffSpecs = map[uint16]tagSpec{
+	0x0100: {name: "ImageWidth", types: fS | fL, count: 1},
+	0x0101: {name: "ImageLength", types: fS | fL, count: 1},
+	0x0102: {name: "BitsPerSample", types: fS, count: 3},
+	0x0103: {name: "Compression", types: fS, count: 1, valid: between(1, 1, 6)},
+	0x0106: {name: "PhotometricInterpretation", types: fS, count: 1, valid: between(2, 2, 6)},
+	0x010e: {name: "ImageDescription", types: fA},
+	0x010f: {name: "Make", types: fA},
+	0x0110: {name: "Model", types: fA},
+	0x0111: {name: "StripOffsets", types: fS | fL},
+	0x0112: {name: "Orientation", types: fS, count: 1, valid: between(1, 8)},
+	0x0115: {name: "SamplesPerPixel", types: fS, count: 1, valid: between(3, 3)},
+	0x0116: {name: "RowsPerStrip", types: fS | fL, count: 1},
+	0x0117: {name: "StripByteCounts", types: fS | fL},
+	0x011a: {name: "XResolution", types: fR, count: 1, mandatory: true},
+	0x011b: {name: "YResolution", types: fR, count: 1, mandatory: true},
+	0x011c: {name: "PlanarConfiguration", types: fS, count: 1, valid: between(1, 2)},
+	0x0128: {name: "ResolutionUnit", types: fS, count: 1, mandatory: true, valid: between(2, 3)},
+	0x012d: {name: "TransferFunction", types: fS, count: 3 * 256},
+	0x0131: {name: "Software", types: fA},
+	0x0132: {name: "DateTime", types: fA, count: 20},
+	0x013b: {name: "Artist", types: fA},
+	0x013e: {name: "WhitePoint", types: fR, count: 2},
+	0x013f: {name: "PrimaryChromaticities", types: fR, count: 6},
+	0x0201: {name: "JPEGInterchangeFormat", types: fL, count: 1},
+	0x0202: {name: "JPEGInterchangeFormatLength", types: fL, count: 1},
+	0x0211: {name: "YCbCrCoefficients", types: fR, count: 3},
+	0x0212: {name: "YCbCrSubSampling", types: fS, count: 2},
+	0x0213: {name: "YCbCrPositioning", types: fS, count: 1, mandatory: true, valid: between(1, 2)},
+	0x0214: {name: "ReferenceBlackWhite", types: fR, count: 6},
+	0x8298: {name: "Copyright", types: fA},
+	0x8769: {name: "ExifIFDPointer", types: fL, count: 1, mandatory: true},
+	0x8825: {name: "GPSInfoIFDPointer", types: fL, count: 1},
+}
+
+// exifSpecs are the tags of the exif IFD.
+var exifSpecs = map[uint16]tagSpec{
+	0x829a: {name: "ExposureTime", types: fR, count: 1},
+	0x829d: {name: "FNumber", types: fR, count: 1},
+	0x8822: {name: "ExposureProgram", types: fS, count: 1, valid: between(0, 8)},
+	0x8824: {name: "SpectralSensitivity", types: fA},
+	0x8827: {name: "PhotographicSensitivity", types: fS},
+	0x8828: {name: "OECF", types: fU},
+	0x8830: {name: "SensitivityType", types: fS, count: 1, valid: between(0, 7)},
+	0x8831: {name: "StandardOutputSensitivity", types: fL, count: 1},
+	0x8832: {name: "RecommendedExposureIndex", types: fL, count: 1},
+	0x8833: {name: "ISOSpeed", types: fL, count: 1},
+	0x8834: {name: "ISOSpeedLatitudeyyy", types: fL, count: 1},
+	0x8835: {name: "ISOSpeedLatitudezzz", types: fL, count: 1},
+	0x9000: {name: "ExifVersion", types: fU, count: 4, mandatory: true},
+	0x9003: {name: "DateTimeOriginal", types: fA, count: 20},
+	0x9004: {name: "DateTimeDigitized", types: fA, count: 20},
+	0x9010: {name: "OffsetTime", types: fA, count: 7},
+	0x9011: {name: "OffsetTimeOriginal", types: fA, count: 7},
+	0x9012: {name: "OffsetTimeDigitized", types: fA, count: 7},
+	0x9101: {name: "ComponentsConfiguration", types: fU, count: 4, mandatory: true},
+	0x9102: {name: "CompressedBitsPerPixel", types: fR, count: 1},
+	0x9201: {name: "ShutterSpeedValue", types: fSR, count: 1},
+	0x9202: {name: "ApertureValue", types: fR, count: 1},
+	0x9203: {name: "BrightnessValue", types: fSR, count: 1},
+	0x9204: {name: "ExposureBiasValue", types: fSR, count: 1},
+	0x9205: {name: "MaxApertureValue", types: fR, count: 1},
+	0x9206: {name: "SubjectDistance", types: fR, count: 1},
+	0x9207: {name: "MeteringMode", types: fS, count: 1, valid: between(0, 6, 255)},
+	0x9208: {name: "LightSource", types: fS, count: 1},
+	0x9209: {name: "Flash", types: fS, count: 1},
+	0x920a: {name: "FocalLength", types: fR, count: 1},
+	0x9214: {name: "SubjectArea", types: fS},
+	0x927c: {name: "MakerNote", types: fU},
+	0x9286: {name: "UserComment", types: fU},
+	0x9290: {name: "SubSecTime", types: fA},
+	0x9291: {name: "SubSecTimeOriginal", types: fA},
+	0x9292: {name: "SubSecTimeDigitized", types: fA},
+	0x9400: {name: "Temperature", types: fSR, count: 1},
+	0x9401: {name: "Humidity", types: fR, count: 1},
+	0x9402: {name: "Pressure", types: fR, count: 1},
+	0x9403: {name: "WaterDepth", types: fSR, count: 1},
+	0x9404: {name: "Acceleration", types: fR, count: 1},
+	0x9405: {name: "CameraElevationAngle", types: fSR, count: 1},
+	0xa000: {name: "FlashpixVersion", types: fU, count: 4, mandatory: true},
+	0xa001: {name: "ColorSpace", types: fS, count: 1, mandatory: true, valid: between(1, 1, 0xffff)},
+	0xa002: {name: "PixelXDimension", types: fS | fL, count: 1, mandatory: true},
+	0xa003: {name: "PixelYDimension", types: fS | fL, count: 1, mandatory: true},
+	0xa004: {name: "RelatedSoundFile", types: fA, count: 13},
+	0xa005: {name: "InteroperabilityIFDPointer", types: fL, count: 1},
+	0xa20b: {name: "FlashEnergy", types: fR, count: 1},
+	0xa20c: {name: "SpatialFrequencyResponse", types: fU},
+	0xa20e: {name: "FocalPlaneXResolution", types: fR, count: 1},
+	0xa20f: {name: "FocalPlaneYResolution", types: fR, count: 1},
+	0xa210: {name: "FocalPlaneResolutionUnit", types: fS, count: 1, valid: between(2, 3)},
+	0xa214: {name: "SubjectLocation", types: fS, count: 2},
+	0xa215: {name: "ExposureIndex", types: fR, count: 1},
+	0xa217: {name: "SensingMethod", types: fS, count: 1, valid: between(1, 5, 7, 8)},
+	0xa300: {name: "FileSource", types: fU, count: 1, valid: between(0, 3)},
+	0xa301: {name: "SceneType", types: fU, count: 1, valid: between(1, 1)},
+	0xa302: {name: "CFAPattern", types: fU},
+	0xa401: {name: "CustomRendered", types: fS, count: 1, valid: between(0, 1)},
+	0xa402: {name: "ExposureMode", types: fS, count: 1, valid: between(0, 2)},
+	0xa403: {name: "WhiteBalance", types: fS, count: 1, valid: between(0, 1)},
+	0xa404: {name: "DigitalZoomRatio", types: fR, count: 1},
+	0xa405: {name: "FocalLengthIn35mmFilm", types: fS, count: 1},
+	0xa406: {name: "SceneCaptureType", types: fS, count: 1, valid: between(0, 3)},
+	0xa407: {name: "GainControl", types: fS, count: 1, valid: between(0, 4)},
+	0xa408: {name: "Contrast", types: fS, count: 1, valid: between(0, 2)},
+	0xa409: {name: "Saturation", types: fS, count: 1, valid: between(0, 2)},
+	0xa40a: {name: "Sharpness", types: fS, count: 1, valid: between(0, 2)},
+	0xa40b: {name: "DeviceSettingDescription", types: fU},
+	0xa40c: {name: "SubjectDistanceRange", types: fS, count: 1, valid: between(0, 3)},
+	0xa420: {name: "ImageUniqueID", types: fA, count: 33},
+	0xa430: {name: "CameraOwnerName", types: fA},
+	0xa431: {name: "BodySerialNumber", types: fA},
+	0xa432: {name: "LensSpecification", types: fR, count: 4},
+	0xa433: {name: "LensMake", types: fA},
+	0xa434: {name: "LensModel", types: fA},
+	0xa435: {name: "LensSerialNumber", types: fA},
+	0xa500: {name: "Gamma", types: fR, count: 1},
+}
+
+// gpsSpecs are the tags of the gps IFD.
+var gpsSpecs = map[uint16]tagSpec{
+	0x00: {name: "GPSVersionID", types: fB, count: 4, mandatory: true},
+	0x01: {name: "GPSLatitudeRef", types: fA, count: 2},
+	0x02: {name: "GPSLatitude", types: fR, count: 3},
+	0x03: {name: "GPSLongitudeRef", types: fA, count: 2},
+	0x04: {name: "GPSLongitude", types: fR, count: 3},
+	0x05: {name: "GPSAltitudeRef", types: fB, count: 1, valid: between(0, 1)},
+	0x06: {name: "GPSAltitude", types: fR, count: 1},
+	0x07: {name: "GPSTimeStamp", types: fR, count: 3},
+	0x08: {name: "GPSSatellites", types: fA},
+	0x09: {name: "GPSStatus", types: fA, count: 2},
+	0x0a: {name: "GPSMeasureMode", types: fA, count: 2},
+	0x0b: {name: "GPSDOP", types: fR, count: 1},
+	0x0c: {name: "GPSSpeedRef", types: fA, count: 2},
+	0x0d: {name: "GPSSpeed", types: fR, count: 1},
+	0x0e: {name: "GPSTrackRef", types: fA, count: 2},
+	0x0f: {name: "GPSTrack", types: fR, count: 1},
+	0x10: {name: "GPSImgDirectionRef", types: fA, count: 2},
+	0x11: {name: "GPSImgDirection", types: fR, count: 1},
+	0x12: {name: "GPSMapDatum", types: fA},
+	0x13: {name: "GPSDestLatitudeRef", types: fA, count: 2},
+	0x14: {name: "GPSDestLatitude", types: fR, count: 3},
+	0x15: {name: "GPSDestLongitudeRef", types: fA, count: 2},
+	0x16: {name: "GPSDestLongitude", types: fR, count: 3},
+	0x17: {name: "GPSDestBearingRef", types: fA, count: 2},
+	0x18: {name: "GPSDestBearing", types: fR, count: 1},
+	0x19: {name: "GPSDestDistanceRef", types: fA, count: 2},
+	0x1a: {name: "GPSDestDistance", types: fR, count: 1},
+	0x1b: {name: "GPSProcessingMethod", types: fU},
+	0x1c: {name: "GPSAreaInformation", types: fU},
+	0x1d: {name: "GPSDateStamp", types: fA, count: 11},
+	0x1e: {name: "GPSDifferential", types: fS, count: 1, valid: between(0, 1)},
+	0x1f: {name: "GPSHPositioningError", types: fR, count: 1},
+}
+
+// interopSpecs are the tags of the interoperability IFD.
+var interopSpecs = map[uint16]tagSpec{
+	0x01: {name: "InteroperabilityIndex", types: fA, count: 4},
+}
+
+// ascii tags with a restricted set of values
+var asciiValues = map[uint16]string{
+	gpsLatitudeRefTag:  "NS",
+	gpsLongitudeRefTag: "EW",
+	0x09:               "AV",
+	0x0a:               "23",
+	0x0c:               "KMN",
+	0x0e:               "TM",
+	0x10:               "TM",
+	0x13:               "NS",
+	0x15:               "EW",
+	0x17:               "TM",
+	0x19:               "KMN",
+}
+
+// Conform check the exif of in against the EXIF 2.32 specification: the
+// mandatory tags of a compressed image, the data formats, counts and values
+// of the tags, and that tags are in the IFD they belong to.
+func Conform(in []byte) (vs []Violation, err error) {
+	defer recoverCorrupt(&err)
+	var b []byte
+	if b, err = exifBlock(in); err != nil {
+		return
+	}
+	var t *tiff
+	if t, err = parseTIFF(b); err != nil {
+		return
+	}
+	vs = t.conform()
+	return
+}
+
+// conform return the violations of t.
+func (t *tiff) conform() (vs []Violation) {
+	ifds := []struct {
+		name  string
+		es    []entry
+		specs map[uint16]tagSpec
+	}{
+		{"ifd0", t.ifd0, tiffSpecs},
+		{"exif", t.exif, exifSpecs},
+		{"gps", t.gps, gpsSpecs},
+		{"interop", t.interop, interopSpecs},
+		{"ifd1", t.ifd1, tiffSpecs},
+	}
+	for _, ifd := range ifds {
+		if len(ifd.es) == 0 && ifd.name != "ifd0" && ifd.name != "exif" {
+			continue
+		}
+		start := len(vs)
+		report := func(tag uint16, name, reason string) {
+			vs = append(vs, Violation{IFD: ifd.name, Tag: tag, Name: name, Reason: reason})
+		}
+		for tag, s := range ifd.specs {
+			if _, ok := lookup(ifd.es, tag); !ok && t.mandatory(ifd.name, tag, s) {
+				report(tag, s.name, "missing mandatory tag")
+			}
+		}
+		for _, e := range ifd.es {
+			s, ok := ifd.specs[e.tag]
+			if !ok {
+				for _, other := range ifds {
+					if o, found := other.specs[e.tag]; found && other.name != "gps" && other.name != "interop" {
+						report(e.tag, o.name, "tag belong to the "+other.name+" IFD")
+						break
+					}
+				}
+				continue
+			}
+			if reason := t.check(e, s); reason != "" {
+				report(e.tag, s.name, reason)
+			}
+		}
+		own := vs[start:]
+		sort.SliceStable(own, func(i, j int) bool { return own[i].Tag < own[j].Tag })
+	}
+	return
+}
+
+// mandatory report whether tag is mandatory in ifd, ifd1 hold the thumbnail
+// instead of the exif pointer.
+func (t *tiff) mandatory(ifd string, tag uint16, s tagSpec) bool {
+	if ifd != "ifd1" {
+		return s.mandatory
+	}
+	switch tag {
+	case exifIFDTag, yCbCrPositioningTag:
+		return false
+	case 0x0103, thumbOffsetTag, thumbLengthTag: // Compression
+		return true
+	}
+	return s.mandatory
+}
+
+// check return why the entry e does not comply with s, or "".
+func (t *tiff) check(e entry, s tagSpec) string {
+	if s.types&(1<<e.typ) == 0 {
+		return fmt.Sprintf("invalid data format %d", e.typ)
+	}
+	if s.count != 0 && e.count != s.count {
+		return fmt.Sprintf("invalid count %d, want %d", e.count, s.count)
+	}
+	switch e.typ {
+	case typeASCII:
+		if len(e.value) == 0 || e.value[len(e.value)-1] != 0 {
+			return "ASCII value not NUL terminated"
+		}
+		v := string(e.value[:len(e.value)-1])
+		if allowed, ok := asciiValues[e.tag]; ok && (len(v) != 1 || strings.IndexByte(allowed, v[0]) < 0) {
+			return fmt.Sprintf("invalid value %q", v)
+		}
+		if s.count == 20 && v != "    :  :     :  :  " {
+			if _, err := time.Parse(dateTimeLayout, v); err != nil {
+				return fmt.Sprintf("invalid date time %q", v)
+			}
+		}
+	case typeByte, typeShort, typeLong, typeUndefined:
+		if s.valid == nil {
+			break
+		}
+		for i := 0; i < int(e.count); i++ {
+			var v uint32
+			switch e.typ {
+			case typeShort:
+				v = uint32(t.order.Uint16(e.value[i*2:]))
+			case typeLong:
+				v = t.order.Uint32(e.value[i*4:])
+			default:
+				v = uint32(e.value[i])
+			}
+			if !s.valid(v) {
+				return fmt.Sprintf("invalid value %d", v)
+			}
+		}
+	}
+	return ""
+}
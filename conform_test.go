@@ -0,0 +1,68 @@
+package exif
+
+import (
+	"encoding/binary"
+	"image"
+	"testing"
+	"time"
+)
+
+func TestConform(t *testing.T) {
+	src, err := StripAll(readFixture(t, filename))
+	if err != nil {
+		t.Fatalf("StripAll error(%v)", err)
+	}
+	payload, err := Build(image.Config{Width: 640, Height: 480}, Params{
+		DateTime: time.Date(2020, 3, 8, 10, 4, 5, 0, time.UTC),
+		GPS:      &GPS{Latitude: 38.88975, Longitude: -77.0089},
+	})
+	if err != nil {
+		t.Fatalf("Build error(%v)", err)
+	}
+	built, err := InsertSegment(src, 0xe1, payload, AfterAPP0)
+	if err != nil {
+		t.Fatalf("InsertSegment error(%v)", err)
+	}
+	if vs, err := Conform(built); err != nil || len(vs) != 0 {
+		t.Fatalf("Conform(Build) = %v, %v, want no violation", vs, err)
+	}
+	order := binary.BigEndian
+	bad := testJPEG(order, []testTag{
+		{orientationTag, typeShort, 1, short(order, 9)},
+		{exifVersionTag, typeUndefined, 4, []byte("0232")},
+	}, []testTag{
+		{colorSpaceTag, typeLong, 1, []byte{0, 0, 0, 1}},
+	})
+	vs, err := Conform(bad)
+	if err != nil {
+		t.Fatalf("Conform error(%v)", err)
+	}
+	want := map[Violation]bool{
+		{IFD: "ifd0", Tag: orientationTag, Name: "Orientation", Reason: "invalid value 9"}:                               true,
+		{IFD: "ifd0", Tag: exifVersionTag, Name: "ExifVersion", Reason: "tag belong to the exif IFD"}:                    true,
+		{IFD: "ifd0", Tag: xResolutionTag, Name: "XResolution", Reason: "missing mandatory tag"}:                         true,
+		{IFD: "exif", Tag: exifVersionTag, Name: "ExifVersion", Reason: "missing mandatory tag"}:                         true,
+		{IFD: "exif", Tag: colorSpaceTag, Name: "ColorSpace", Reason: "invalid data format 4"}:                           true,
+		{IFD: "exif", Tag: pixelXDimensionTag, Name: "PixelXDimension", Reason: "missing mandatory tag"}:                 true,
+		{IFD: "ifd0", Tag: yCbCrPositioningTag, Name: "YCbCrPositioning", Reason: "missing mandatory tag"}:               true,
+		{IFD: "exif", Tag: flashpixVersionTag, Name: "FlashpixVersion", Reason: "missing mandatory tag"}:                 true,
+		{IFD: "ifd0", Tag: resolutionUnitTag, Name: "ResolutionUnit", Reason: "missing mandatory tag"}:                   true,
+		{IFD: "ifd0", Tag: yResolutionTag, Name: "YResolution", Reason: "missing mandatory tag"}:                         true,
+		{IFD: "exif", Tag: pixelYDimensionTag, Name: "PixelYDimension", Reason: "missing mandatory tag"}:                 true,
+		{IFD: "exif", Tag: componentsConfigurationTag, Name: "ComponentsConfiguration", Reason: "missing mandatory tag"}: true,
+	}
+	for _, v := range vs {
+		if !want[v] {
+			t.Errorf("unexpected violation %v", v)
+		}
+		delete(want, v)
+	}
+	for v := range want {
+		t.Errorf("missing violation %v", v)
+	}
+	for i := 1; i < len(vs); i++ {
+		if vs[i-1].IFD == vs[i].IFD && vs[i-1].Tag > vs[i].Tag {
+			t.Fatalf("violations not sorted by tag: %v", vs)
+		}
+	}
+}
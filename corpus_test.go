@@ -0,0 +1,94 @@
+package exif
+
+import (
+	"crypto/sha256"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "update the golden files of the testdata corpus")
+
+// fixture is an image of the testdata corpus.
+type fixture struct {
+	name string
+	data []byte
+}
+
+// readFixture return the content of testdata/name.
+func readFixture(t testing.TB, name string) []byte {
+	b, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatalf("os.ReadFile(%s) error(%v)", name, err)
+	}
+	return b
+}
+
+// corpus return every jpg image of testdata.
+func corpus(t testing.TB) (fs []fixture) {
+	names, err := filepath.Glob(filepath.Join("testdata", "*.jpg"))
+	if err != nil {
+		t.Fatalf("filepath.Glob error(%v)", err)
+	}
+	for _, name := range names {
+		name = filepath.Base(name)
+		fs = append(fs, fixture{name: name, data: readFixture(t, name)})
+	}
+	return
+}
+
+// digest return an operation recording the sha256 of the output of strip.
+func digest(strip func([]byte) ([]byte, error)) func([]byte) string {
+	return func(in []byte) string {
+		out, err := strip(in)
+		if err != nil {
+			return "error: " + err.Error()
+		}
+		return fmt.Sprintf("%d bytes, sha256 %x", len(out), sha256.Sum256(out))
+	}
+}
+
+// goldenOps are the operations whose results are recorded in the golden files.
+var goldenOps = []struct {
+	name string
+	run  func([]byte) string
+}{
+	{"Strip", digest(Strip)},
+	{"StripAll", digest(StripAll)},
+	{"Process", digest(NewProcessor(Options{KeepOrientation: true}).Process)},
+	{"FocalLength35mm", func(in []byte) string {
+		f, err := FocalLength35mm(in)
+		if err != nil {
+			return "error: " + err.Error()
+		}
+		return fmt.Sprint(f)
+	}},
+}
+
+// TestCorpus compare the results of goldenOps on the corpus with
+// testdata/*.golden, run go test -update to rewrite them.
+func TestCorpus(t *testing.T) {
+	for _, f := range corpus(t) {
+		var b strings.Builder
+		for _, op := range goldenOps {
+			fmt.Fprintf(&b, "%s: %s\n", op.name, op.run(f.data))
+		}
+		path := filepath.Join("testdata", strings.TrimSuffix(f.name, ".jpg")+".golden")
+		if *update {
+			if err := os.WriteFile(path, []byte(b.String()), 0666); err != nil {
+				t.Fatalf("os.WriteFile(%s) error(%v)", path, err)
+			}
+			continue
+		}
+		want, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatalf("os.ReadFile(%s) error(%v), run go test -update to create it", path, err)
+		}
+		if got := b.String(); got != string(want) {
+			t.Errorf("%s results differ from %s\ngot:\n%swant:\n%s", f.name, path, got, want)
+		}
+	}
+}
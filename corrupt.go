@@ -0,0 +1,80 @@
+package exif
+
+import (
+	"errors"
+	"fmt"
+	"runtime/debug"
+)
+
+// ErrCorrupt is returned for input so malformed that decoding it panicked,
+// the panic is recovered and the error is a *CorruptError. No exported
+// function of the package panic on malformed input, the panics of the
+// callbacks of the caller are forwarded as a *CallbackPanic.
+var ErrCorrupt = errors.New("corrupt input")
+
+// CorruptError is the error of a recovered panic.
+type CorruptError struct {
+	Value interface{} // value passed to panic
+	Stack []byte      // stack of the panicking goroutine
+}
+
+// Error implement error.
+func (e *CorruptError) Error() string {
+	return fmt.Sprintf("%v: %v", ErrCorrupt, e.Value)
+}
+
+// Is report whether target is ErrCorrupt.
+func (e *CorruptError) Is(target error) bool {
+	return target == ErrCorrupt
+}
+
+// CallbackPanic is the panic value forwarded out of the package for a panic
+// of a callback of the caller, like a SegmentHandler, a Formatter, a Fetcher
+// or a Putter. It is a bug of the caller, not corrupt input, so it is not
+// recovered as ErrCorrupt nor passed to PanicHook.
+type CallbackPanic struct {
+	Value interface{} // value passed to panic
+	Stack []byte      // stack of the panicking goroutine
+}
+
+// Error implement error.
+func (p *CallbackPanic) Error() string {
+	return fmt.Sprintf("callback panic: %v", p.Value)
+}
+
+// PanicHook is called with each recovered panic if not nil, like to report
+// it to crash telemetry with the offending input. It must be safe for
+// concurrent use and be set before using the package.
+var PanicHook func(err *CorruptError)
+
+// recoverCorrupt set *err to a *CorruptError if the caller is panicking, it
+// must be deferred by the exported functions. The panics of callbacks run
+// through callback are forwarded.
+func recoverCorrupt(err *error) {
+	v := recover()
+	if v == nil {
+		return
+	}
+	if p, ok := v.(*CallbackPanic); ok {
+		panic(p)
+	}
+	e := &CorruptError{Value: v, Stack: debug.Stack()}
+	if PanicHook != nil {
+		PanicHook(e)
+	}
+	*err = e
+}
+
+// callback call fn, which run a callback of the caller, so its panic is
+// forwarded as a *CallbackPanic instead of being recovered as ErrCorrupt.
+func callback(fn func()) {
+	defer func() {
+		if v := recover(); v != nil {
+			if _, ok := v.(*CallbackPanic); !ok {
+				v = &CallbackPanic{Value: v, Stack: debug.Stack()}
+			}
+			panic(v)
+		}
+	}()
+	fn()
+}
@@ -0,0 +1,95 @@
+package exif
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+)
+
+// Exif is the decoded exif metadata of an image. It implements io.WriterTo
+// and io.ReaderFrom so it can be moved to and from raw exif byte APIs.
+type Exif struct {
+	t *tiff
+}
+
+// Decode return the exif of the jpeg image in.
+func Decode(in []byte) (e *Exif, err error) {
+	defer recoverCorrupt(&err)
+	var b []byte
+	if b, err = exifBlock(in); err != nil {
+		return
+	}
+	return ParseTIFF(b)
+}
+
+// ParseTIFF return the exif held by the raw tiff block b, as found after the
+// exif header of the APP1 payload. A leading exif header is skipped.
+func ParseTIFF(b []byte) (e *Exif, err error) {
+	defer recoverCorrupt(&err)
+	b = bytes.TrimPrefix(b, exifPrefix)
+	var t *tiff
+	if t, err = parseTIFF(append([]byte(nil), b...)); err != nil {
+		return
+	}
+	e = &Exif{t: t}
+	return
+}
+
+// ReadFrom replace e with the exif held by the raw tiff block read from r
+// until EOF, see ParseTIFF.
+func (e *Exif) ReadFrom(r io.Reader) (n int64, err error) {
+	defer recoverCorrupt(&err)
+	var b []byte
+	b, err = io.ReadAll(io.LimitReader(r, maxBlockSize+int64(len(exifPrefix))+1))
+	if n = int64(len(b)); err != nil {
+		return
+	}
+	if n > maxBlockSize+int64(len(exifPrefix)) {
+		err = ErrInvalidBlockSize
+		return
+	}
+	var t *tiff
+	if t, err = parseTIFF(bytes.TrimPrefix(b, exifPrefix)); err != nil {
+		return
+	}
+	e.t = t
+	return
+}
+
+// WriteTo write the APP1 payload of e, the exif header followed by the tiff
+// block, to w.
+func (e *Exif) WriteTo(w io.Writer) (n int64, err error) {
+	defer recoverCorrupt(&err)
+	var (
+		payload []byte
+		m       int
+	)
+	if payload, _, err = e.block().app1(); err != nil {
+		return
+	}
+	m, err = w.Write(payload)
+	n = int64(m)
+	return
+}
+
+// Encode return the APP1 payload of e like WriteTo, with the values aligned
+// and space reserved as set by l. If l keep the maker note and it had to be
+// moved, the payload is returned with ErrMakerNoteMoved.
+func (e *Exif) Encode(l Layout) (payload []byte, err error) {
+	defer recoverCorrupt(&err)
+	t := *e.block()
+	t.layout = l
+	payload, moved, err := t.app1()
+	if err == nil && moved {
+		err = ErrMakerNoteMoved
+	}
+	return
+}
+
+// block return the tiff of e, the zero Exif is an empty big endian block.
+func (e *Exif) block() *tiff {
+	if e.t == nil {
+		e.t = &tiff{order: binary.BigEndian}
+	}
+	return e.t
+}
@@ -0,0 +1,68 @@
+package exif
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestExifWriteToReadFrom(t *testing.T) {
+	for _, name := range []string{"exif_bigEndian.jpg", "exif_littleEndian.jpg", "jfif_bigEndian.jpg"} {
+		src := readFixture(t, name)
+		e, err := Decode(src)
+		if err != nil {
+			t.Fatalf("Decode(%s) error(%v)", name, err)
+		}
+		var payload bytes.Buffer
+		if n, err := e.WriteTo(&payload); err != nil || n != int64(payload.Len()) {
+			t.Fatalf("WriteTo(%s) = %d, %v", name, n, err)
+		}
+		if !bytes.HasPrefix(payload.Bytes(), exifPrefix) {
+			t.Fatalf("WriteTo(%s) did not write the exif header", name)
+		}
+		var got Exif
+		if _, err = got.ReadFrom(bytes.NewReader(payload.Bytes())); err != nil {
+			t.Fatalf("ReadFrom(%s) error(%v)", name, err)
+		}
+		var again bytes.Buffer
+		if _, err = got.WriteTo(&again); err != nil || !bytes.Equal(again.Bytes(), payload.Bytes()) {
+			t.Fatalf("ReadFrom(%s) error(%v), exif differ after a round trip", name, err)
+		}
+		b, _ := exifBlock(src)
+		raw, err := ParseTIFF(b)
+		if err != nil {
+			t.Fatalf("ParseTIFF(%s) error(%v)", name, err)
+		}
+		if v, _ := raw.t.uint(raw.t.ifd0, orientationTag); name != "jfif_bigEndian.jpg" && v != 6 {
+			t.Fatalf("ParseTIFF(%s) orientation = %d, want 6", name, v)
+		}
+	}
+	var zero Exif
+	var payload bytes.Buffer
+	if _, err := zero.WriteTo(&payload); err != nil {
+		t.Fatalf("WriteTo(zero) error(%v)", err)
+	}
+	if _, err := ParseTIFF(payload.Bytes()); err != nil {
+		t.Fatalf("ParseTIFF(zero) error(%v)", err)
+	}
+}
+
+func TestExifEncode(t *testing.T) {
+	e, err := Decode(readFixture(t, filename))
+	if err != nil {
+		t.Fatalf("Decode error(%v)", err)
+	}
+	var plain bytes.Buffer
+	if _, err = e.WriteTo(&plain); err != nil {
+		t.Fatalf("WriteTo error(%v)", err)
+	}
+	if b, err := e.Encode(Layout{}); err != nil || !bytes.Equal(b, plain.Bytes()) {
+		t.Fatalf("Encode(Layout{}) error(%v), differ from WriteTo", err)
+	}
+	padded, err := e.Encode(Layout{Align: 4, Padding: 2048})
+	if err != nil || len(padded) < plain.Len()+2048 {
+		t.Fatalf("Encode = %d bytes, %v, want at least %d", len(padded), err, plain.Len()+2048)
+	}
+	if _, ok := lookup(e.t.ifd0, paddingTag); ok {
+		t.Fatalf("Encode modified e")
+	}
+}
@@ -0,0 +1,51 @@
+package exif
+
+// SetDimensions set the pixel dimensions of the image, after a resize. The
+// dimension tags of IFD0 are updated too if present.
+func (e *Exif) SetDimensions(width, height int) (err error) {
+	defer recoverCorrupt(&err)
+	if width < 0 || height < 0 || uint64(width) > 0xffffffff || uint64(height) > 0xffffffff {
+		err = ErrInvalidTagValue
+		return
+	}
+	t := e.block()
+	t.exif = t.setDimension(t.exif, pixelXDimensionTag, uint32(width), true)
+	t.exif = t.setDimension(t.exif, pixelYDimensionTag, uint32(height), true)
+	t.ifd0 = t.setDimension(t.ifd0, imageWidthTag, uint32(width), false)
+	t.ifd0 = t.setDimension(t.ifd0, imageLengthTag, uint32(height), false)
+	return
+}
+
+// Dimensions return the pixel dimensions recorded in the exif IFD.
+func (e *Exif) Dimensions() (width, height int, err error) {
+	defer recoverCorrupt(&err)
+	t := e.block()
+	w, wok := t.uint(t.exif, pixelXDimensionTag)
+	h, hok := t.uint(t.exif, pixelYDimensionTag)
+	if !wok || !hok {
+		err = ErrTagNotFound
+		return
+	}
+	width, height = int(w), int(h)
+	return
+}
+
+// RemoveThumbnail remove the thumbnail, IFD1 describing it is dropped when
+// encoding.
+func (e *Exif) RemoveThumbnail() {
+	e.block().thumbnail = nil
+}
+
+// setDimension return es with tag set to v, keeping a SHORT format when v
+// fit. tag is only added if add is true.
+func (t *tiff) setDimension(es []entry, tag uint16, v uint32, add bool) []entry {
+	old, ok := lookup(es, tag)
+	if !ok && !add {
+		return es
+	}
+	es = remove(es, tag)
+	if ok && old.typ == typeShort && v <= 0xffff {
+		return append(es, t.shorts(tag, uint16(v)))
+	}
+	return append(es, t.longs(tag, v))
+}
@@ -0,0 +1,54 @@
+package exif
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+)
+
+func TestEditDependents(t *testing.T) {
+	order := binary.LittleEndian
+	src := testJPEG(order, []testTag{{imageWidthTag, typeShort, 1, short(order, 640)}}, []testTag{
+		{pixelXDimensionTag, typeShort, 1, short(order, 640)},
+		{pixelYDimensionTag, typeShort, 1, short(order, 480)},
+	})
+	e, err := Decode(src)
+	if err != nil {
+		t.Fatalf("Decode error(%v)", err)
+	}
+	if err = e.SetDimensions(100000, 75); err != nil {
+		t.Fatalf("SetDimensions error(%v)", err)
+	}
+	var payload bytes.Buffer
+	if _, err = e.WriteTo(&payload); err != nil {
+		t.Fatalf("WriteTo error(%v)", err)
+	}
+	if e, err = ParseTIFF(payload.Bytes()); err != nil {
+		t.Fatalf("ParseTIFF error(%v)", err)
+	}
+	if w, h, err := e.Dimensions(); err != nil || w != 100000 || h != 75 {
+		t.Fatalf("Dimensions = %d, %d, %v", w, h, err)
+	}
+	if v, _ := e.t.uint(e.t.ifd0, imageWidthTag); v != 100000 {
+		t.Fatalf("ImageWidth = %d, want 100000", v)
+	}
+	if _, ok := lookup(e.t.ifd0, imageLengthTag); ok {
+		t.Fatalf("SetDimensions added ImageLength to ifd0")
+	}
+	if y, _ := lookup(e.t.exif, pixelYDimensionTag); y.typ != typeShort {
+		t.Fatalf("PixelYDimension format %d, want SHORT", y.typ)
+	}
+
+	th := &tiff{order: order, ifd0: []entry{}, thumbnail: []byte{0xff, 0xd8, 0xff, 0xd9}}
+	th.ifd1 = []entry{th.shorts(0x0103, 6)}
+	if e, err = ParseTIFF(th.encode()); err != nil || len(e.t.ifd1) == 0 {
+		t.Fatalf("ParseTIFF error(%v), ifd1 = %v", err, e.t.ifd1)
+	}
+	e.RemoveThumbnail()
+	if e, err = ParseTIFF(e.t.encode()); err != nil {
+		t.Fatalf("ParseTIFF error(%v)", err)
+	}
+	if len(e.t.ifd1) != 0 || e.t.thumbnail != nil {
+		t.Fatalf("ifd1 = %v, thumbnail %x after RemoveThumbnail", e.t.ifd1, e.t.thumbnail)
+	}
+}
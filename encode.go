@@ -0,0 +1,351 @@
+package exif
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sort"
+)
+
+// maxBlockSize is the largest tiff block fitting in an APP1 segment.
+const maxBlockSize = 0xffff - 2 - 6
+
+// tag ids treated specially by encode
+const (
+	paddingTag   = 0xea1c // reserve space
+	makerNoteTag = 0x927c
+)
+
+// ErrMakerNoteMoved is returned when the maker note can not be kept at its
+// offset, which break the maker notes holding absolute offsets.
+var ErrMakerNoteMoved = errors.New("maker note moved")
+
+// ErrSegmentTooLarge is returned when an encoded exif do not fit in the
+// 65535 bytes of an APP1 segment, the error is a *SegmentTooLargeError.
+var ErrSegmentTooLarge = errors.New("exif segment too large")
+
+// SegmentTooLargeError is the error of an encoded exif Overflow bytes
+// larger than an APP1 segment can hold.
+type SegmentTooLargeError struct {
+	Overflow int
+}
+
+// Error implement error.
+func (e *SegmentTooLargeError) Error() string {
+	return fmt.Sprintf("%v by %d bytes", ErrSegmentTooLarge, e.Overflow)
+}
+
+// Is report whether target is ErrSegmentTooLarge, or ErrInvalidBlockSize
+// which was returned before.
+func (e *SegmentTooLargeError) Is(target error) bool {
+	return target == ErrSegmentTooLarge || target == ErrInvalidBlockSize
+}
+
+// Layout configure how a tiff block is encoded.
+type Layout struct {
+	// Align is the alignment of the values and IFDs: 2, the default, 4 or
+	// 8, which some parsers expect.
+	Align int
+	// Padding is the size of a zeroed Padding tag added to IFD0, which
+	// reserve space to grow tags with later in-place edits.
+	Padding int
+	// KeepMakerNote write the MakerNote value verbatim at the offset it was
+	// read from, after the IFDs, as some maker notes hold absolute offsets
+	// into the block. It is moved if the IFDs grew past it.
+	KeepMakerNote bool
+	// DropThumbnail remove the thumbnail if the exif would not fit in an
+	// APP1 segment otherwise.
+	DropThumbnail bool
+	// Version is the EXIF version written as ExifVersion, like Version232.
+	// If empty, a valid ExifVersion is kept and a missing one is written as
+	// Version232. The mandatory FlashpixVersion and ComponentsConfiguration
+	// are added if missing, blocks without exif IFD and the minimal exif
+	// kept by the strip functions are left minimal.
+	Version string
+}
+
+// exif versions of Layout.Version
+const (
+	Version221 = "0221"
+	Version23  = "0230"
+	Version232 = "0232"
+	Version30  = "0300"
+)
+
+// versions are the exif versions a block can target.
+var versions = map[string]bool{Version221: true, Version23: true, Version232: true, Version30: true}
+
+// align return the alignment of l.
+func (l Layout) align() int {
+	if l.Align == 4 || l.Align == 8 {
+		return l.Align
+	}
+	return 2
+}
+
+// encode serialize t into a tiff block. The IFDs are written in the order
+// ifd0, exif, interop, gps and ifd1 followed by the thumbnail, sub IFD
+// pointers and thumbnail offsets are set from the layout, entries are sorted
+// by tag and values are aligned as set by the layout of t. ifd1 is dropped
+// if it describe no thumbnail anymore.
+func (t *tiff) encode() (b []byte) {
+	b, _ = t.encodeMakerNote()
+	return
+}
+
+// encodeMakerNote is encode, moved report whether the maker note kept by
+// the layout was moved.
+func (t *tiff) encodeMakerNote() (b []byte, moved bool) {
+	exif := t.setPointer(t.exif, interopIFDTag, len(t.interop) > 0)
+	if len(exif) > 0 && !t.minimal {
+		exif = t.versionTags(exif)
+	}
+	for i := range exif {
+		if e := &exif[i]; t.layout.KeepMakerNote && e.tag == makerNoteTag && e.offset != 0 {
+			e.fixed = e.offset // placeholder until the IFDs are laid out
+		}
+	}
+	ifd0 := t.setPointer(t.ifd0, exifIFDTag, len(exif) > 0)
+	if t.layout.Padding > 0 {
+		exif = remove(exif, paddingTag)
+		ifd0 = append(remove(ifd0, paddingTag), t.undefined(paddingTag, make([]byte, t.layout.Padding)))
+	}
+	ifd0 = t.setPointer(ifd0, gpsIFDTag, len(t.gps) > 0)
+	ifd1 := t.setPointer(t.ifd1, thumbOffsetTag, len(t.thumbnail) > 0)
+	ifd1 = remove(ifd1, thumbLengthTag)
+	if len(t.thumbnail) > 0 {
+		ifd1 = append(ifd1, t.longs(thumbLengthTag, uint32(len(t.thumbnail))))
+	} else if _, ok := lookup(ifd1, stripOffsetsTag); !ok {
+		ifd1 = nil
+	}
+	ifds := [][]entry{ifd0, exif, t.interop, t.gps, ifd1}
+	offsets := make([]uint32, len(ifds))
+	off, align := 8, t.layout.align()
+	for i, es := range ifds {
+		if len(es) == 0 && i > 0 {
+			continue
+		}
+		es = append([]entry(nil), es...)
+		sort.Slice(es, func(i, j int) bool { return es[i].tag < es[j].tag })
+		ifds[i], offsets[i] = es, uint32(off)
+		off += ifdSize(es, align)
+	}
+	var maker entry // kept maker note, written after the IFDs
+	for i := range ifds[1] {
+		if e := &ifds[1][i]; e.fixed != 0 {
+			if int(e.offset) < off {
+				e.fixed, moved = uint32(off), true
+			}
+			maker = *e
+			off = int(e.fixed) + len(e.value)
+			off += pad(off, align)
+		}
+	}
+	t.order.PutUint32(pointer(ifds[0], exifIFDTag), offsets[1])
+	t.order.PutUint32(pointer(ifds[1], interopIFDTag), offsets[2])
+	t.order.PutUint32(pointer(ifds[0], gpsIFDTag), offsets[3])
+	t.order.PutUint32(pointer(ifds[4], thumbOffsetTag), uint32(off))
+
+	b = make([]byte, 8, off+len(t.thumbnail))
+	if t.order == binary.LittleEndian {
+		binary.BigEndian.PutUint16(b, byteOrderLE)
+	} else {
+		binary.BigEndian.PutUint16(b, byteOrderBE)
+	}
+	t.order.PutUint16(b[2:], byteOrderExt)
+	t.order.PutUint32(b[4:], 8)
+	for i, es := range ifds {
+		if len(es) == 0 && i > 0 {
+			continue
+		}
+		var next uint32
+		if i == 0 {
+			next = offsets[4]
+		}
+		b = t.writeIFD(b, es, next, align)
+	}
+	if maker.fixed != 0 {
+		b = append(b, make([]byte, int(maker.fixed)-len(b))...)
+		b = append(b, maker.value...)
+		b = append(b, make([]byte, pad(len(b), align))...)
+	}
+	return append(b, t.thumbnail...), moved
+}
+
+// versionTags return the exif IFD es with the version tags the layout
+// target, and the mandatory FlashpixVersion and ComponentsConfiguration.
+func (t *tiff) versionTags(es []entry) []entry {
+	v, ok := lookup(es, exifVersionTag)
+	if t.layout.Version != "" || !ok || !validVersion(v.value) {
+		version := t.layout.Version
+		if version == "" {
+			version = Version232
+		}
+		es = append(remove(es, exifVersionTag), t.undefined(exifVersionTag, []byte(version)))
+	}
+	if v, ok := lookup(es, flashpixVersionTag); !ok || !validVersion(v.value) {
+		es = append(remove(es, flashpixVersionTag), t.undefined(flashpixVersionTag, []byte("0100")))
+	}
+	if v, ok := lookup(es, componentsConfigurationTag); !ok || len(v.value) != 4 {
+		es = append(remove(es, componentsConfigurationTag), t.undefined(componentsConfigurationTag, []byte{1, 2, 3, 0}))
+	}
+	return es
+}
+
+// validVersion report whether v is a version tag value of four digits, like "0232".
+func validVersion(v []byte) bool {
+	if len(v) != 4 {
+		return false
+	}
+	for _, c := range v {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// writeIFD append es and their values to b, which end at the IFD offset.
+func (t *tiff) writeIFD(b []byte, es []entry, next uint32, align int) []byte {
+	data := len(b) + 2 + len(es)*12 + 4
+	data += pad(data, align)
+	b = t.order.AppendUint16(b, uint16(len(es)))
+	var values []byte
+	for _, e := range es {
+		b = t.order.AppendUint16(b, e.tag)
+		b = t.order.AppendUint16(b, e.typ)
+		b = t.order.AppendUint32(b, e.count)
+		if len(e.value) <= 4 {
+			var v [4]byte
+			copy(v[:], e.value)
+			b = append(b, v[:]...)
+			continue
+		}
+		if e.fixed != 0 {
+			b = t.order.AppendUint32(b, e.fixed)
+			continue
+		}
+		b = t.order.AppendUint32(b, uint32(data+len(values)))
+		values = append(values, e.value...)
+		values = append(values, make([]byte, pad(data+len(values), align))...)
+	}
+	b = t.order.AppendUint32(b, next)
+	b = append(b, make([]byte, pad(len(b), align))...)
+	return append(b, values...)
+}
+
+// ifdSize return the encoded size of an IFD holding es at an aligned offset.
+func ifdSize(es []entry, align int) (n int) {
+	n = 2 + len(es)*12 + 4
+	n += pad(n, align)
+	for _, e := range es {
+		if len(e.value) > 4 && e.fixed == 0 {
+			n += len(e.value) + pad(len(e.value), align)
+		}
+	}
+	return
+}
+
+// pad return the number of bytes aligning n.
+func pad(n, align int) int {
+	return (align - n%align) % align
+}
+
+// setPointer return a copy of es holding a zero LONG tag if set, or without tag otherwise.
+func (t *tiff) setPointer(es []entry, tag uint16, set bool) []entry {
+	es = remove(es, tag)
+	if set {
+		es = append(es, t.longs(tag, 0))
+	}
+	return es
+}
+
+// pointer return the value of tag in es, or a scratch buffer if es has no tag.
+func pointer(es []entry, tag uint16) []byte {
+	if e, ok := lookup(es, tag); ok {
+		return e.value
+	}
+	return make([]byte, 4)
+}
+
+// remove return a copy of es without tag.
+func remove(es []entry, tag uint16) []entry {
+	out := make([]entry, 0, len(es)+1)
+	for _, e := range es {
+		if e.tag != tag {
+			out = append(out, e)
+		}
+	}
+	return out
+}
+
+// app1 return the APP1 payload of t, dropping the thumbnail to fit if the
+// layout allow it, or ErrInvalidTagValue if it target an unknown version.
+// moved report whether the kept maker note was moved.
+func (t *tiff) app1() (payload []byte, moved bool, err error) {
+	if t.layout.Version != "" && !versions[t.layout.Version] {
+		err = ErrInvalidTagValue
+		return
+	}
+	b, moved := t.encodeMakerNote()
+	if len(b) > maxBlockSize && t.layout.DropThumbnail && len(t.thumbnail) > 0 {
+		small := *t
+		small.ifd1, small.thumbnail = nil, nil
+		b, moved = small.encodeMakerNote()
+	}
+	payload, err = app1(b)
+	return
+}
+
+// app1 return the APP1 payload holding the tiff block b.
+func app1(b []byte) (payload []byte, err error) {
+	if len(b) > maxBlockSize {
+		err = &SegmentTooLargeError{Overflow: len(b) - maxBlockSize}
+		return
+	}
+	payload = append(append(make([]byte, 0, len(exifPrefix)+len(b)), exifPrefix...), b...)
+	return
+}
+
+// ascii return a NUL terminated ASCII entry.
+func (t *tiff) ascii(tag uint16, s string) entry {
+	v := append([]byte(s), 0)
+	return entry{tag: tag, typ: typeASCII, count: uint32(len(v)), value: v}
+}
+
+// undefined return an UNDEFINED entry.
+func (t *tiff) undefined(tag uint16, v []byte) entry {
+	return entry{tag: tag, typ: typeUndefined, count: uint32(len(v)), value: v}
+}
+
+// bytes return a BYTE entry.
+func (t *tiff) bytes(tag uint16, v ...byte) entry {
+	return entry{tag: tag, typ: typeByte, count: uint32(len(v)), value: v}
+}
+
+// shorts return a SHORT entry.
+func (t *tiff) shorts(tag uint16, v ...uint16) entry {
+	e := entry{tag: tag, typ: typeShort, count: uint32(len(v))}
+	for _, x := range v {
+		e.value = t.order.AppendUint16(e.value, x)
+	}
+	return e
+}
+
+// longs return a LONG entry.
+func (t *tiff) longs(tag uint16, v ...uint32) entry {
+	e := entry{tag: tag, typ: typeLong, count: uint32(len(v))}
+	for _, x := range v {
+		e.value = t.order.AppendUint32(e.value, x)
+	}
+	return e
+}
+
+// rationals return a RATIONAL entry of numerator, denominator pairs.
+func (t *tiff) rationals(tag uint16, v ...uint32) entry {
+	e := entry{tag: tag, typ: typeRational, count: uint32(len(v) / 2)}
+	for _, x := range v[:len(v)/2*2] {
+		e.value = t.order.AppendUint32(e.value, x)
+	}
+	return e
+}
@@ -0,0 +1,246 @@
+package exif
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"math/rand"
+	"sort"
+	"testing"
+)
+
+// layoutTags are set by encode from the layout.
+var layoutTags = map[uint16]bool{exifIFDTag: true, gpsIFDTag: true, interopIFDTag: true, thumbOffsetTag: true, thumbLengthTag: true}
+
+// randomIFD return up to 24 entries with distinct tags, random data formats and counts.
+func randomIFD(r *rand.Rand, t *tiff) (es []entry) {
+	seen := make(map[uint16]bool)
+	for n := r.Intn(25); len(es) < n; {
+		tag := uint16(r.Intn(0x10000))
+		if seen[tag] || layoutTags[tag] {
+			continue
+		}
+		seen[tag] = true
+		e := entry{tag: tag, typ: uint16(1 + r.Intn(len(typeSize)-1)), count: uint32(r.Intn(12))}
+		e.value = make([]byte, typeSize[e.typ]*int(e.count))
+		r.Read(e.value)
+		es = append(es, e)
+	}
+	return
+}
+
+// sameEntries report whether got, without layout tags, hold the entries of want.
+func sameEntries(got, want []entry) bool {
+	var filtered []entry
+	for _, e := range got {
+		if !layoutTags[e.tag] {
+			filtered = append(filtered, e)
+		}
+	}
+	want = append([]entry(nil), want...)
+	sort.Slice(want, func(i, j int) bool { return want[i].tag < want[j].tag })
+	if len(filtered) != len(want) {
+		return false
+	}
+	for i, e := range want {
+		f := filtered[i]
+		if f.tag != e.tag || f.typ != e.typ || f.count != e.count || !bytes.Equal(f.value, e.value) {
+			return false
+		}
+	}
+	return true
+}
+
+// withVersion return the exif IFD of in as encoded, with the version tags.
+func withVersion(in *tiff) []entry {
+	if len(in.exif) == 0 && len(in.interop) == 0 {
+		return in.exif
+	}
+	return in.versionTags(in.exif)
+}
+
+func TestEncodeRoundTrip(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 500; i++ {
+		in := &tiff{order: binary.BigEndian}
+		if i%2 == 1 {
+			in.order = binary.LittleEndian
+		}
+		in.ifd0 = randomIFD(r, in)
+		in.exif = randomIFD(r, in)
+		in.interop = randomIFD(r, in)
+		in.gps = randomIFD(r, in)
+		in.ifd1 = randomIFD(r, in)
+		if r.Intn(2) == 0 {
+			in.thumbnail = make([]byte, 1+r.Intn(64))
+			r.Read(in.thumbnail)
+		}
+		wantIFD1 := in.ifd1
+		if _, ok := lookup(in.ifd1, stripOffsetsTag); !ok && len(in.thumbnail) == 0 {
+			wantIFD1 = nil // ifd1 describe no image
+		}
+		b := in.encode()
+		out, err := parseTIFF(b)
+		if err != nil {
+			t.Fatalf("#%d parseTIFF error(%v)", i, err)
+		}
+		if out.order != in.order {
+			t.Fatalf("#%d byte order = %v, want %v", i, out.order, in.order)
+		}
+		for name, ifd := range map[string][2][]entry{
+			"ifd0":    {out.ifd0, in.ifd0},
+			"exif":    {out.exif, withVersion(in)},
+			"interop": {out.interop, in.interop},
+			"gps":     {out.gps, in.gps},
+			"ifd1":    {out.ifd1, wantIFD1},
+		} {
+			if !sameEntries(ifd[0], ifd[1]) {
+				t.Fatalf("#%d %s = %v, want %v", i, name, ifd[0], ifd[1])
+			}
+		}
+		if !bytes.Equal(out.thumbnail, in.thumbnail) {
+			t.Fatalf("#%d thumbnail = %x, want %x", i, out.thumbnail, in.thumbnail)
+		}
+		if again := out.encode(); !bytes.Equal(again, b) {
+			t.Fatalf("#%d encode(parseTIFF(b)) differ from b", i)
+		}
+	}
+}
+
+func TestEncodeLayout(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 200; i++ {
+		in := &tiff{order: binary.LittleEndian, layout: Layout{Align: []int{2, 4, 8}[i%3], Padding: i % 2 * 100}}
+		in.ifd0 = randomIFD(r, in)
+		in.exif = randomIFD(r, in)
+		in.gps = randomIFD(r, in)
+		in.thumbnail = []byte{0xff, 0xd8, 0xff, 0xd9}
+		b := in.encode()
+		out, err := parseTIFF(b)
+		if err != nil {
+			t.Fatalf("#%d parseTIFF error(%v)", i, err)
+		}
+		want := in.ifd0
+		if in.layout.Padding > 0 {
+			want = append(remove(want, paddingTag), in.undefined(paddingTag, make([]byte, in.layout.Padding)))
+		}
+		if !sameEntries(out.ifd0, want) || !sameEntries(out.exif, withVersion(in)) || !sameEntries(out.gps, in.gps) {
+			t.Fatalf("#%d entries differ after encoding with %+v", i, in.layout)
+		}
+		for _, es := range [][]entry{out.ifd0, out.exif, out.gps, out.ifd1} {
+			for _, e := range es {
+				if off := offsetIn(b, e.value); len(e.value) > 4 && off%int64(in.layout.Align) != 0 {
+					t.Fatalf("#%d tag %#x value at %d, want %d aligned", i, e.tag, off, in.layout.Align)
+				}
+			}
+		}
+		if off := offsetIn(b, out.thumbnail); off%int64(in.layout.Align) != 0 {
+			t.Fatalf("#%d thumbnail at %d, want %d aligned", i, off, in.layout.Align)
+		}
+	}
+}
+
+func TestEncodeKeepMakerNote(t *testing.T) {
+	order := binary.BigEndian
+	maker := bytes.Repeat([]byte("NOTE"), 16)
+	src := &tiff{order: order}
+	src.ifd0 = []entry{src.ascii(softwareTag, "a long software name pushing the maker note away")}
+	src.exif = []entry{src.undefined(makerNoteTag, maker), src.ascii(0x9290, "123456789")}
+	in, err := parseTIFF(src.encode())
+	if err != nil {
+		t.Fatalf("parseTIFF error(%v)", err)
+	}
+	e, _ := lookup(in.exif, makerNoteTag)
+	// drop the software tag, the maker note can stay where it was
+	in.ifd0 = remove(in.ifd0, softwareTag)
+	in.layout.KeepMakerNote = true
+	b, moved := in.encodeMakerNote()
+	out, err := parseTIFF(b)
+	if err != nil || moved {
+		t.Fatalf("parseTIFF error(%v), moved %v", err, moved)
+	}
+	if got, _ := lookup(out.exif, makerNoteTag); got.offset != e.offset || !bytes.Equal(got.value, maker) {
+		t.Fatalf("maker note at %d, want %d", got.offset, e.offset)
+	}
+	if sub, _ := lookup(out.exif, 0x9290); string(sub.value) != "123456789\x00" {
+		t.Fatalf("SubSecTime = %q", sub.value)
+	}
+	// the IFDs grow past the maker note
+	in.layout.Padding = 256
+	if b, moved = in.encodeMakerNote(); !moved {
+		t.Fatalf("maker note not reported moved")
+	}
+	if out, err = parseTIFF(b); err != nil {
+		t.Fatalf("parseTIFF error(%v)", err)
+	}
+	if got, _ := lookup(out.exif, makerNoteTag); !bytes.Equal(got.value, maker) {
+		t.Fatalf("maker note = %q after moving", got.value)
+	}
+}
+
+func TestEncodeTooLarge(t *testing.T) {
+	order := binary.BigEndian
+	th := &tiff{order: order, thumbnail: make([]byte, 70000)}
+	th.ifd0 = []entry{th.shorts(orientationTag, 6)}
+	e := &Exif{t: th}
+	_, err := e.Encode(Layout{})
+	var tl *SegmentTooLargeError
+	if !errors.As(err, &tl) || !errors.Is(err, ErrSegmentTooLarge) {
+		t.Fatalf("Encode error(%v), want ErrSegmentTooLarge", err)
+	}
+	if want := len(th.encode()) - maxBlockSize; tl.Overflow != want {
+		t.Fatalf("Overflow = %d, want %d", tl.Overflow, want)
+	}
+	payload, err := e.Encode(Layout{DropThumbnail: true})
+	if err != nil {
+		t.Fatalf("Encode DropThumbnail error(%v)", err)
+	}
+	out, err := ParseTIFF(payload)
+	if err != nil {
+		t.Fatalf("ParseTIFF error(%v)", err)
+	}
+	if out.t.thumbnail != nil || out.t.ifd1 != nil {
+		t.Fatalf("thumbnail of %d bytes kept", len(out.t.thumbnail))
+	}
+	if o, _ := out.t.uint(out.t.ifd0, orientationTag); o != 6 {
+		t.Fatalf("Orientation = %d, want 6", o)
+	}
+	if e.t.thumbnail == nil {
+		t.Fatalf("Encode removed the thumbnail of e")
+	}
+}
+
+func TestEncodeVersion(t *testing.T) {
+	in := &tiff{order: binary.BigEndian}
+	in.exif = []entry{in.undefined(exifVersionTag, []byte("2.3")), in.shorts(colorSpaceTag, 1)}
+	for _, c := range []struct {
+		version, want string
+	}{
+		{"", Version232},
+		{Version221, "0221"},
+		{Version30, "0300"},
+	} {
+		in.layout.Version = c.version
+		payload, _, err := in.app1()
+		if err != nil {
+			t.Fatalf("app1(%q) error(%v)", c.version, err)
+		}
+		out, _ := parseTIFF(payload[len(exifPrefix):])
+		v, _ := lookup(out.exif, exifVersionTag)
+		fpx, _ := lookup(out.exif, flashpixVersionTag)
+		cc, _ := lookup(out.exif, componentsConfigurationTag)
+		if string(v.value) != c.want || string(fpx.value) != "0100" || !bytes.Equal(cc.value, []byte{1, 2, 3, 0}) {
+			t.Fatalf("app1(%q) ExifVersion %q FlashpixVersion %q ComponentsConfiguration %v, want %q", c.version, v.value, fpx.value, cc.value, c.want)
+		}
+	}
+	in.exif[0] = in.undefined(exifVersionTag, []byte("0220"))
+	in.layout.Version = ""
+	payload, _, _ := in.app1()
+	if out, _ := parseTIFF(payload[len(exifPrefix):]); string(pointer(out.exif, exifVersionTag)) != "0220" {
+		t.Fatal("app1 replaced a valid ExifVersion")
+	}
+	in.layout.Version = "0231"
+	if _, _, err := in.app1(); err != ErrInvalidTagValue {
+		t.Fatalf("app1(0231) error(%v), want ErrInvalidTagValue", err)
+	}
+}
@@ -5,7 +5,7 @@ import (
 	"encoding/binary"
 	"errors"
 	"io"
-	"io/ioutil"
+	"math/bits"
 )
 
 // JPEG图片exif格式如下：
@@ -33,6 +33,8 @@ import (
 const (
 	markerSOI      = 0xffd8
 	markerAPP1     = 0xffe1
+	markerSOS      = 0xffda
+	markerEOI      = 0xffd9
 	byteHeader     = 0x45786966
 	byteHeaderExt  = 0x0000
 	byteOrderBE    = 0x4d4d
@@ -50,14 +52,16 @@ var (
 	ErrInvalidOrderFlag = errors.New("invalid byte order flag")
 	ErrInvalidOffset    = errors.New("invalid offset value")
 	ErrInvalidTagValue  = errors.New("invalid tag value")
+	ErrTagNotFound      = errors.New("tag not found")
 )
 
 // Strip remove exif except orientation.
 func Strip(in []byte) (out []byte, err error) {
+	defer recoverCorrupt(&err)
 	r := bytes.NewReader(in)
 	// Check if JPEG SOI marker is present.
 	var soi uint16
-	if err = binary.Read(r, binary.BigEndian, &soi); err != nil {
+	if soi, err = readUint16(r, binary.BigEndian); err != nil {
 		return
 	}
 	if soi != markerSOI {
@@ -71,10 +75,10 @@ func Strip(in []byte) (out []byte, err error) {
 	)
 	for {
 		var marker, size uint16
-		if err = binary.Read(r, binary.BigEndian, &marker); err != nil {
+		if marker, err = readUint16(r, binary.BigEndian); err != nil {
 			return
 		}
-		if err = binary.Read(r, binary.BigEndian, &size); err != nil {
+		if size, err = readUint16(r, binary.BigEndian); err != nil {
 			return
 		}
 		if marker>>8 != 0xff {
@@ -90,7 +94,7 @@ func Strip(in []byte) (out []byte, err error) {
 			break
 		}
 		index = index + int(size) + 2
-		if _, err = io.CopyN(ioutil.Discard, r, int64(size)-2); err != nil {
+		if err = skip(r, int64(size)-2); err != nil {
 			return
 		}
 	}
@@ -98,24 +102,35 @@ func Strip(in []byte) (out []byte, err error) {
 		err = ErrNoExif
 		return
 	}
+	if index+2+esize > len(in) {
+		err = ErrInvalidBlockSize
+		return
+	}
+	// Remove APP1 without tiff data, read the tiff data only within APP1.
+	payload := in[index+4 : index+2+esize]
+	if emptyExif(payload) {
+		out, err = splice(in, index, esize+2, nil)
+		return
+	}
+	r = bytes.NewReader(payload)
 	// Check if EXIF header is present.
 	var header uint32
-	if err = binary.Read(r, binary.BigEndian, &header); err != nil {
+	if header, err = readUint32(r, binary.BigEndian); err != nil {
 		return
 	}
 	if header != byteHeader {
 		err = ErrInvalidHeader
 		return
 	}
-	if _, err = io.CopyN(ioutil.Discard, r, 2); err != nil { // skip two byte header ext
+	if err = skip(r, 2); err != nil { // skip two byte header ext
 		return
 	}
 	// Read byte order information.
 	var (
 		byteOrderTag uint16
-		byteOrder    binary.ByteOrder
+		byteOrder    byteOrder
 	)
-	if err = binary.Read(r, binary.BigEndian, &byteOrderTag); err != nil { // two byte ByteOrder
+	if byteOrderTag, err = readUint16(r, binary.BigEndian); err != nil { // two byte ByteOrder
 		return
 	}
 	switch byteOrderTag {
@@ -127,71 +142,71 @@ func Strip(in []byte) (out []byte, err error) {
 		err = ErrInvalidOrderFlag
 		return
 	}
-	if _, err = io.CopyN(ioutil.Discard, r, 2); err != nil { // skip two byte ByteOrder ext
+	if err = skip(r, 2); err != nil { // skip two byte ByteOrder ext
 		return
 	}
 	var offset uint32
-	if err = binary.Read(r, byteOrder, &offset); err != nil {
+	if offset, err = readUint32(r, byteOrder); err != nil {
 		return
 	}
 	if offset < 8 {
 		err = ErrInvalidOffset
 		return
 	}
-	if _, err = io.CopyN(ioutil.Discard, r, int64(offset-8)); err != nil { // seek to IFD0
+	if err = skip(r, int64(offset-8)); err != nil { // seek to IFD0
 		return
 	}
 	var tagNum uint16
-	if err = binary.Read(r, byteOrder, &tagNum); err != nil { // read the number of tags
+	if tagNum, err = readUint16(r, byteOrder); err != nil { // read the number of tags
 		return
 	}
-	ow := new(bytes.Buffer) // orientation io writer
+	var (
+		ow    [12]byte // orientation tag
+		found bool
+	)
 	for i := 0; i < int(tagNum); i++ {
 		var tag uint16
-		if err = binary.Read(r, byteOrder, &tag); err != nil {
+		if tag, err = readUint16(r, byteOrder); err != nil {
 			return
 		}
 		if tag != orientationTag {
-			if _, err = io.CopyN(ioutil.Discard, r, 10); err != nil {
+			if err = skip(r, 10); err != nil {
 				return
 			}
 			continue
 		}
-		binary.Write(ow, byteOrder, uint16(orientationTag)) // write orientation tag id
-		if _, err = io.CopyN(ow, r, 10); err != nil {
+		byteOrder.PutUint16(ow[:], uint16(orientationTag)) // write orientation tag id
+		if err = readFull(r, ow[2:]); err != nil {
 			return
 		}
+		found = true
 		break
 	}
-	ew := new(bytes.Buffer) // exif io writer
-	if ow.Len() > 0 {       // if there is orientation in exif,remain orientation tag
-		binary.Write(ew, binary.BigEndian, uint16(markerAPP1))    // write app1 marker
-		binary.Write(ew, binary.BigEndian, uint16(0x001e))        // write app1 size
-		binary.Write(ew, binary.BigEndian, uint32(byteHeader))    // write exif header
-		binary.Write(ew, binary.BigEndian, uint16(byteHeaderExt)) // write exif header ext
-		binary.Write(ew, binary.BigEndian, uint16(byteOrderTag))  // write byte order
-		binary.Write(ew, byteOrder, uint16(byteOrderExt))         // write byte order ext
-		binary.Write(ew, byteOrder, uint32(0x00000008))           // write offset:0
-		binary.Write(ew, byteOrder, uint16(0x0001))               // write tag number:1
-		io.Copy(ew, ow)                                           // write orientation tag
-	}
-	w := new(bytes.Buffer)
-	r.Seek(0, io.SeekStart)
-	// Write SOI part
-	io.CopyN(w, r, int64(index))
-	// Skip exif
-	io.CopyN(ioutil.Discard, r, int64(esize)+2)
+	var ew []byte // exif part
+	if found {    // if there is orientation in exif,remain orientation tag
+		ew = make([]byte, 0, 32)
+		ew = binary.BigEndian.AppendUint16(ew, uint16(markerAPP1))    // write app1 marker
+		ew = binary.BigEndian.AppendUint16(ew, uint16(0x001e))        // write app1 size
+		ew = binary.BigEndian.AppendUint32(ew, uint32(byteHeader))    // write exif header
+		ew = binary.BigEndian.AppendUint16(ew, uint16(byteHeaderExt)) // write exif header ext
+		ew = binary.BigEndian.AppendUint16(ew, uint16(byteOrderTag))  // write byte order
+		ew = byteOrder.AppendUint16(ew, uint16(byteOrderExt))         // write byte order ext
+		ew = byteOrder.AppendUint32(ew, uint32(0x00000008))           // write offset:0
+		ew = byteOrder.AppendUint16(ew, uint16(0x0001))               // write tag number:1
+		ew = append(ew, ow[:]...)                                     // write orientation tag
+	}
 	// Combine SOI part,orientation exif part and data toghter
-	out, err = ioutil.ReadAll(io.MultiReader(w, ew, r))
+	out, err = splice(in, index, esize+2, ew)
 	return
 }
 
 // StripAll remove exif.
 func StripAll(in []byte) (out []byte, err error) {
+	defer recoverCorrupt(&err)
 	r := bytes.NewReader(in)
 	// Check if JPEG SOI marker is present.
 	var soi uint16
-	if err = binary.Read(r, binary.BigEndian, &soi); err != nil {
+	if soi, err = readUint16(r, binary.BigEndian); err != nil {
 		return
 	}
 	if soi != markerSOI {
@@ -205,10 +220,10 @@ func StripAll(in []byte) (out []byte, err error) {
 	)
 	for {
 		var marker, size uint16
-		if err = binary.Read(r, binary.BigEndian, &marker); err != nil {
+		if marker, err = readUint16(r, binary.BigEndian); err != nil {
 			return
 		}
-		if err = binary.Read(r, binary.BigEndian, &size); err != nil {
+		if size, err = readUint16(r, binary.BigEndian); err != nil {
 			return
 		}
 		if marker>>8 != 0xff {
@@ -224,7 +239,7 @@ func StripAll(in []byte) (out []byte, err error) {
 			break
 		}
 		index = index + int(size) + 2
-		if _, err = io.CopyN(ioutil.Discard, r, int64(size)-2); err != nil {
+		if err = skip(r, int64(size)-2); err != nil {
 			return
 		}
 	}
@@ -232,13 +247,80 @@ func StripAll(in []byte) (out []byte, err error) {
 		err = ErrNoExif
 		return
 	}
-	w := new(bytes.Buffer)
-	r.Seek(0, io.SeekStart)
-	// Write SOI part
-	io.CopyN(w, r, int64(index))
-	// Skip exif
-	io.CopyN(ioutil.Discard, r, int64(esize)+2)
 	// Combine SOI part and data toghter
-	out, err = ioutil.ReadAll(io.MultiReader(w, r))
+	out, err = splice(in, index, esize+2, nil)
+	return
+}
+
+// emptyExif report whether the APP1 payload p hold no tiff data, that is p is
+// empty or hold the exif header, possibly truncated or followed by padding zeros.
+func emptyExif(p []byte) bool {
+	if !bytes.HasPrefix(p, exifPrefix) {
+		return bytes.HasPrefix(exifPrefix, p)
+	}
+	for _, c := range p[len(exifPrefix):] {
+		if c != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// readUint16 read two bytes of r in order.
+func readUint16(r *bytes.Reader, order binary.ByteOrder) (v uint16, err error) {
+	var b [2]byte
+	if err = readFull(r, b[:]); err != nil {
+		return
+	}
+	// decode with the concrete big endian order, calling order would make b escape
+	if v = binary.BigEndian.Uint16(b[:]); order == binary.LittleEndian {
+		v = bits.ReverseBytes16(v)
+	}
+	return
+}
+
+// readUint32 read four bytes of r in order.
+func readUint32(r *bytes.Reader, order binary.ByteOrder) (v uint32, err error) {
+	var b [4]byte
+	if err = readFull(r, b[:]); err != nil {
+		return
+	}
+	// decode with the concrete big endian order, calling order would make b escape
+	if v = binary.BigEndian.Uint32(b[:]); order == binary.LittleEndian {
+		v = bits.ReverseBytes32(v)
+	}
+	return
+}
+
+// readFull is io.ReadFull for a bytes.Reader, it does not make b escape to the heap.
+func readFull(r *bytes.Reader, b []byte) (err error) {
+	if n, _ := r.Read(b); n < len(b) {
+		if err = io.ErrUnexpectedEOF; n == 0 {
+			err = io.EOF
+		}
+	}
+	return
+}
+
+// skip advance r by n bytes, it return io.EOF if r has less than n bytes left.
+func skip(r *bytes.Reader, n int64) (err error) {
+	if int64(r.Len()) < n {
+		r.Seek(0, io.SeekEnd)
+		return io.EOF
+	}
+	_, err = r.Seek(n, io.SeekCurrent)
+	return
+}
+
+// splice return in with the size bytes at index replaced by b.
+func splice(in []byte, index, size int, b []byte) (out []byte, err error) {
+	if index+size > len(in) {
+		err = ErrInvalidBlockSize
+		return
+	}
+	out = make([]byte, 0, len(in)-size+len(b))
+	out = append(out, in[:index]...)
+	out = append(out, b...)
+	out = append(out, in[index+size:]...)
 	return
 }
@@ -1,51 +1,101 @@
 package exif
 
 import (
-	"io/ioutil"
-	"os"
+	"bytes"
 	"testing"
 )
 
 var filename = "exif_bigEndian.jpg"
 
 func TestStrip(t *testing.T) {
-	var (
-		err      error
-		file     *os.File
-		src, dst []byte
-	)
-	if file, err = os.Open(filename); err != nil {
-		t.Fatalf("os.Open(%s) error(%v)", filename, err)
-	}
-	defer file.Close()
-	if src, err = ioutil.ReadAll(file); err != nil {
-		t.Fatalf("ioutil.ReadAll error(%v)", err)
-	}
-	if dst, err = Strip(src); err != nil {
+	src := readFixture(t, filename)
+	dst, err := Strip(src)
+	if err != nil {
 		t.Fatalf("strip exif error(%v)", err)
 	}
-	if err = ioutil.WriteFile("strip.jpg", dst, 0666); err != nil {
-		t.Fatalf("ioutil.WriteFile() error(%v)", err)
+	b, err := exifBlock(dst)
+	if err != nil {
+		t.Fatalf("exifBlock error(%v)", err)
+	}
+	tf, err := parseTIFF(b)
+	if err != nil {
+		t.Fatalf("parseTIFF error(%v)", err)
+	}
+	if v, _ := tf.uint(tf.ifd0, orientationTag); len(tf.ifd0) != 1 || v != 6 {
+		t.Fatalf("strip exif ifd0 = %v, want orientation 6 only", tf.ifd0)
 	}
 }
 
 func TestStripAll(t *testing.T) {
-	var (
-		err      error
-		file     *os.File
-		src, dst []byte
-	)
-	if file, err = os.Open(filename); err != nil {
-		t.Fatalf("os.Open(%s) error(%v)", filename, err)
-	}
-	defer file.Close()
-	if src, err = ioutil.ReadAll(file); err != nil {
-		t.Fatalf("ioutil.ReadAll error(%v)", err)
-	}
-	if dst, err = StripAll(src); err != nil {
+	src := readFixture(t, filename)
+	dst, err := StripAll(src)
+	if err != nil {
 		t.Fatalf("strip all exif error(%v)", err)
 	}
-	if err = ioutil.WriteFile("strip_all.jpg", dst, 0666); err != nil {
-		t.Fatalf("ioutil.WriteFile() error(%v)", err)
+	if _, err = exifBlock(dst); err != ErrNoExif {
+		t.Fatalf("exifBlock error(%v), want %v", err, ErrNoExif)
+	}
+}
+
+// benchFiles is the benchmark corpus, small files are built from the large ones.
+var benchFiles = []string{"exif_bigEndian.jpg", "exif_littleEndian.jpg", "jfif_bigEndian.jpg"}
+
+func benchmarkStrip(b *testing.B, strip func([]byte) ([]byte, error)) {
+	for _, name := range benchFiles {
+		large := readFixture(b, name)
+		// small keep the metadata segments and only a few bytes of image data
+		_, end, err := readSegments(large)
+		if err != nil {
+			b.Fatalf("readSegments(%s) error(%v)", name, err)
+		}
+		small := append(append([]byte{}, large[:end+64]...), 0xff, 0xd9)
+		noexif, err := StripAll(large)
+		if err != nil {
+			b.Fatalf("StripAll(%s) error(%v)", name, err)
+		}
+		for _, c := range []struct {
+			name string
+			src  []byte
+		}{{"large", large}, {"small", small}, {"noexif", noexif}} {
+			b.Run(name+"/"+c.name, func(b *testing.B) {
+				b.SetBytes(int64(len(c.src)))
+				b.ReportAllocs()
+				for i := 0; i < b.N; i++ {
+					strip(c.src)
+				}
+			})
+		}
+	}
+}
+
+func BenchmarkStrip(b *testing.B) {
+	benchmarkStrip(b, Strip)
+}
+
+func BenchmarkStripAll(b *testing.B) {
+	benchmarkStrip(b, StripAll)
+}
+
+func TestStripEmptyExif(t *testing.T) {
+	src := readFixture(t, filename)
+	want, err := StripAll(src)
+	if err != nil {
+		t.Fatalf("StripAll error(%v)", err)
+	}
+	p := NewProcessor(Options{KeepOrientation: true})
+	for _, payload := range []string{"", "Exif", "Exif\x00\x00", "Exif\x00\x00\x00\x00\x00\x00\x00\x00"} {
+		in, err := InsertSegment(want, 0xe1, []byte(payload), AfterSOI)
+		if err != nil {
+			t.Fatalf("InsertSegment(%q) error(%v)", payload, err)
+		}
+		for name, strip := range map[string]func([]byte) ([]byte, error){"Strip": Strip, "StripAll": StripAll, "Process": p.Process} {
+			out, err := strip(in)
+			if err != nil {
+				t.Fatalf("%s(%q) error(%v)", name, payload, err)
+			}
+			if !bytes.Equal(out, want) {
+				t.Fatalf("%s(%q) did not remove the empty APP1", name, payload)
+			}
+		}
 	}
 }
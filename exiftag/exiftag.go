@@ -0,0 +1,169 @@
+// Package exiftag define the ids of exif tags, for exif.Get.
+package exiftag
+
+// ID is a tag id, the low 16 bits are the tag and the high bits the IFD
+// holding it, 0 for IFD0 and the exif IFD, whose tags are distinct.
+type ID uint32
+
+// IFD namespaces of ids
+const (
+	GPS     ID = 1 << 16 // gps IFD
+	Interop ID = 2 << 16 // interoperability IFD
+)
+
+// IFD0 tags
+const (
+	ImageWidth                  ID = 0x0100
+	ImageLength                 ID = 0x0101
+	BitsPerSample               ID = 0x0102
+	Compression                 ID = 0x0103
+	PhotometricInterpretation   ID = 0x0106
+	ImageDescription            ID = 0x010e
+	Make                        ID = 0x010f
+	Model                       ID = 0x0110
+	StripOffsets                ID = 0x0111
+	Orientation                 ID = 0x0112
+	SamplesPerPixel             ID = 0x0115
+	RowsPerStrip                ID = 0x0116
+	StripByteCounts             ID = 0x0117
+	XResolution                 ID = 0x011a
+	YResolution                 ID = 0x011b
+	PlanarConfiguration         ID = 0x011c
+	ResolutionUnit              ID = 0x0128
+	TransferFunction            ID = 0x012d
+	Software                    ID = 0x0131
+	DateTime                    ID = 0x0132
+	Artist                      ID = 0x013b
+	WhitePoint                  ID = 0x013e
+	PrimaryChromaticities       ID = 0x013f
+	JPEGInterchangeFormat       ID = 0x0201
+	JPEGInterchangeFormatLength ID = 0x0202
+	YCbCrCoefficients           ID = 0x0211
+	YCbCrSubSampling            ID = 0x0212
+	YCbCrPositioning            ID = 0x0213
+	ReferenceBlackWhite         ID = 0x0214
+	Copyright                   ID = 0x8298
+)
+
+// exif IFD tags
+const (
+	ExposureTime              ID = 0x829a
+	FNumber                   ID = 0x829d
+	ExposureProgram           ID = 0x8822
+	SpectralSensitivity       ID = 0x8824
+	PhotographicSensitivity   ID = 0x8827
+	OECF                      ID = 0x8828
+	SensitivityType           ID = 0x8830
+	StandardOutputSensitivity ID = 0x8831
+	RecommendedExposureIndex  ID = 0x8832
+	ISOSpeed                  ID = 0x8833
+	ISOSpeedLatitudeyyy       ID = 0x8834
+	ISOSpeedLatitudezzz       ID = 0x8835
+	ExifVersion               ID = 0x9000
+	DateTimeOriginal          ID = 0x9003
+	DateTimeDigitized         ID = 0x9004
+	OffsetTime                ID = 0x9010
+	OffsetTimeOriginal        ID = 0x9011
+	OffsetTimeDigitized       ID = 0x9012
+	ComponentsConfiguration   ID = 0x9101
+	CompressedBitsPerPixel    ID = 0x9102
+	ShutterSpeedValue         ID = 0x9201
+	ApertureValue             ID = 0x9202
+	BrightnessValue           ID = 0x9203
+	ExposureBiasValue         ID = 0x9204
+	MaxApertureValue          ID = 0x9205
+	SubjectDistance           ID = 0x9206
+	MeteringMode              ID = 0x9207
+	LightSource               ID = 0x9208
+	Flash                     ID = 0x9209
+	FocalLength               ID = 0x920a
+	SubjectArea               ID = 0x9214
+	MakerNote                 ID = 0x927c
+	UserComment               ID = 0x9286
+	SubSecTime                ID = 0x9290
+	SubSecTimeOriginal        ID = 0x9291
+	SubSecTimeDigitized       ID = 0x9292
+	Temperature               ID = 0x9400
+	Humidity                  ID = 0x9401
+	Pressure                  ID = 0x9402
+	WaterDepth                ID = 0x9403
+	Acceleration              ID = 0x9404
+	CameraElevationAngle      ID = 0x9405
+	FlashpixVersion           ID = 0xa000
+	ColorSpace                ID = 0xa001
+	PixelXDimension           ID = 0xa002
+	PixelYDimension           ID = 0xa003
+	RelatedSoundFile          ID = 0xa004
+	FlashEnergy               ID = 0xa20b
+	SpatialFrequencyResponse  ID = 0xa20c
+	FocalPlaneXResolution     ID = 0xa20e
+	FocalPlaneYResolution     ID = 0xa20f
+	FocalPlaneResolutionUnit  ID = 0xa210
+	SubjectLocation           ID = 0xa214
+	ExposureIndex             ID = 0xa215
+	SensingMethod             ID = 0xa217
+	FileSource                ID = 0xa300
+	SceneType                 ID = 0xa301
+	CFAPattern                ID = 0xa302
+	CustomRendered            ID = 0xa401
+	ExposureMode              ID = 0xa402
+	WhiteBalance              ID = 0xa403
+	DigitalZoomRatio          ID = 0xa404
+	FocalLengthIn35mmFilm     ID = 0xa405
+	SceneCaptureType          ID = 0xa406
+	GainControl               ID = 0xa407
+	Contrast                  ID = 0xa408
+	Saturation                ID = 0xa409
+	Sharpness                 ID = 0xa40a
+	DeviceSettingDescription  ID = 0xa40b
+	SubjectDistanceRange      ID = 0xa40c
+	ImageUniqueID             ID = 0xa420
+	CameraOwnerName           ID = 0xa430
+	BodySerialNumber          ID = 0xa431
+	LensSpecification         ID = 0xa432
+	LensMake                  ID = 0xa433
+	LensModel                 ID = 0xa434
+	LensSerialNumber          ID = 0xa435
+	Gamma                     ID = 0xa500
+)
+
+// gps IFD tags
+const (
+	GPSVersionID         ID = GPS | 0x00
+	GPSLatitudeRef       ID = GPS | 0x01
+	GPSLatitude          ID = GPS | 0x02
+	GPSLongitudeRef      ID = GPS | 0x03
+	GPSLongitude         ID = GPS | 0x04
+	GPSAltitudeRef       ID = GPS | 0x05
+	GPSAltitude          ID = GPS | 0x06
+	GPSTimeStamp         ID = GPS | 0x07
+	GPSSatellites        ID = GPS | 0x08
+	GPSStatus            ID = GPS | 0x09
+	GPSMeasureMode       ID = GPS | 0x0a
+	GPSDOP               ID = GPS | 0x0b
+	GPSSpeedRef          ID = GPS | 0x0c
+	GPSSpeed             ID = GPS | 0x0d
+	GPSTrackRef          ID = GPS | 0x0e
+	GPSTrack             ID = GPS | 0x0f
+	GPSImgDirectionRef   ID = GPS | 0x10
+	GPSImgDirection      ID = GPS | 0x11
+	GPSMapDatum          ID = GPS | 0x12
+	GPSDestLatitudeRef   ID = GPS | 0x13
+	GPSDestLatitude      ID = GPS | 0x14
+	GPSDestLongitudeRef  ID = GPS | 0x15
+	GPSDestLongitude     ID = GPS | 0x16
+	GPSDestBearingRef    ID = GPS | 0x17
+	GPSDestBearing       ID = GPS | 0x18
+	GPSDestDistanceRef   ID = GPS | 0x19
+	GPSDestDistance      ID = GPS | 0x1a
+	GPSProcessingMethod  ID = GPS | 0x1b
+	GPSAreaInformation   ID = GPS | 0x1c
+	GPSDateStamp         ID = GPS | 0x1d
+	GPSDifferential      ID = GPS | 0x1e
+	GPSHPositioningError ID = GPS | 0x1f
+)
+
+// interoperability IFD tags
+const (
+	InteroperabilityIndex ID = Interop | 0x01
+)
@@ -0,0 +1,100 @@
+// Package exiftest provide assertions on the exif of jpeg images, for the
+// regression tests of image pipelines.
+package exiftest
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/lkzz/exif"
+	"github.com/lkzz/exif/exiftag"
+)
+
+// pointer tags, which are set by the encoder
+var pointers = map[uint16]bool{0x8769: true, 0x8825: true, 0xa005: true}
+
+// decode return the exif of data, nil if it has none.
+func decode(t testing.TB, data []byte) (e *exif.Exif, ok bool) {
+	t.Helper()
+	e, err := exif.Decode(data)
+	switch err {
+	case nil:
+	case exif.ErrNoExif:
+		e = nil
+	default:
+		t.Errorf("exif.Decode error(%v)", err)
+		return nil, false
+	}
+	return e, true
+}
+
+// id return the exiftag id of the tag id, ok is false for IFD1 tags.
+func id(tid exif.TagID) (v exiftag.ID, ok bool) {
+	switch tid.IFD {
+	case "ifd0", "exif":
+		return exiftag.ID(tid.Tag), true
+	case "gps":
+		return exiftag.GPS | exiftag.ID(tid.Tag), true
+	case "interop":
+		return exiftag.Interop | exiftag.ID(tid.Tag), true
+	}
+	return
+}
+
+// AssertNoGPS report an error if data has a gps tag.
+func AssertNoGPS(t testing.TB, data []byte) {
+	t.Helper()
+	e, ok := decode(t, data)
+	if !ok || e == nil {
+		return
+	}
+	for tid := range e.Tags() {
+		if tid.IFD == "gps" {
+			t.Errorf("gps tag %#04x not removed", tid.Tag)
+		}
+	}
+}
+
+// AssertTagEqual report an error if the tag id of data is missing or is
+// not want, see exif.Get.
+func AssertTagEqual[T exif.Value](t testing.TB, data []byte, id exiftag.ID, want T) {
+	t.Helper()
+	e, ok := decode(t, data)
+	if !ok {
+		return
+	}
+	if e == nil {
+		t.Errorf("tag %#x: no exif", uint32(id))
+		return
+	}
+	got, err := exif.Get[T](e, id)
+	if err != nil {
+		t.Errorf("tag %#x: %v", uint32(id), err)
+		return
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("tag %#x = %v, want %v", uint32(id), got, want)
+	}
+}
+
+// AssertStrippedExcept report an error for each exif tag of data which is
+// not in keep, including the thumbnail tags of IFD1.
+func AssertStrippedExcept(t testing.TB, data []byte, keep ...exiftag.ID) {
+	t.Helper()
+	e, ok := decode(t, data)
+	if !ok || e == nil {
+		return
+	}
+	kept := make(map[exiftag.ID]bool, len(keep))
+	for _, k := range keep {
+		kept[k] = true
+	}
+	for tid := range e.Tags() {
+		if pointers[tid.Tag] {
+			continue
+		}
+		if v, ok := id(tid); !ok || !kept[v] {
+			t.Errorf("tag %s/%#04x not stripped", tid.IFD, tid.Tag)
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package exiftest
+
+import (
+	"fmt"
+	"image"
+	"os"
+	"testing"
+
+	"github.com/lkzz/exif"
+	"github.com/lkzz/exif/exiftag"
+)
+
+// recorder is a testing.TB recording the errors instead of failing.
+type recorder struct {
+	testing.TB
+	errs []string
+}
+
+func (r *recorder) Helper() {}
+
+func (r *recorder) Errorf(format string, args ...interface{}) {
+	r.errs = append(r.errs, fmt.Sprintf(format, args...))
+}
+
+// testImage return a jpeg holding the exif built with p.
+func testImage(t *testing.T, p exif.Params) []byte {
+	in, err := os.ReadFile("../testdata/exif_littleEndian.jpg")
+	if err != nil {
+		t.Fatalf("ReadFile error(%v)", err)
+	}
+	if in, err = exif.StripAll(in); err != nil {
+		t.Fatalf("StripAll error(%v)", err)
+	}
+	payload, err := exif.Build(image.Config{Width: 4, Height: 3}, p)
+	if err != nil {
+		t.Fatalf("Build error(%v)", err)
+	}
+	if in, err = exif.SetRawExif(in, payload); err != nil {
+		t.Fatalf("SetRawExif error(%v)", err)
+	}
+	return in
+}
+
+func TestAssertions(t *testing.T) {
+	located := testImage(t, exif.Params{Orientation: 6, GPS: &exif.GPS{Latitude: 1, Longitude: 2}})
+	plain := testImage(t, exif.Params{Orientation: 6})
+	for _, c := range []struct {
+		name  string
+		check func(testing.TB)
+		fails int
+	}{
+		{"NoGPS", func(tb testing.TB) { AssertNoGPS(tb, plain) }, 0},
+		{"GPS", func(tb testing.TB) { AssertNoGPS(tb, located) }, 5},
+		{"TagEqual", func(tb testing.TB) { AssertTagEqual(tb, plain, exiftag.Orientation, uint16(6)) }, 0},
+		{"TagDiffer", func(tb testing.TB) { AssertTagEqual(tb, plain, exiftag.Orientation, uint16(1)) }, 1},
+		{"TagMissing", func(tb testing.TB) { AssertTagEqual(tb, plain, exiftag.Artist, "x") }, 1},
+		{"StrippedExcept", func(tb testing.TB) {
+			out, _ := exif.Strip(plain)
+			AssertStrippedExcept(tb, out, exiftag.Orientation)
+		}, 0},
+		{"NotStripped", func(tb testing.TB) { AssertStrippedExcept(tb, plain, exiftag.Orientation) }, 10},
+		{"NotJPEG", func(tb testing.TB) { AssertNoGPS(tb, []byte("gif")) }, 1},
+	} {
+		r := &recorder{TB: t}
+		c.check(r)
+		if len(r.errs) != c.fails {
+			t.Errorf("%s: errors %q, want %d", c.name, r.errs, c.fails)
+		}
+	}
+}
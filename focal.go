@@ -0,0 +1,89 @@
+package exif
+
+import "math"
+
+// tag ids used by focal length computation
+const (
+	imageWidthTag               = 0x0100
+	imageLengthTag              = 0x0101
+	focalLengthTag              = 0x920a
+	pixelXDimensionTag          = 0xa002
+	pixelYDimensionTag          = 0xa003
+	focalPlaneXResolutionTag    = 0xa20e
+	focalPlaneYResolutionTag    = 0xa20f
+	focalPlaneResolutionUnitTag = 0xa210
+	focalLengthIn35mmFilmTag    = 0xa405
+)
+
+// fullFrameDiagonal is the diagonal of 36x24mm film in millimetres.
+var fullFrameDiagonal = math.Hypot(36, 24)
+
+// FocalLength35mm return the 35mm-equivalent focal length in millimetres.
+// FocalLengthIn35mmFilm is used if present, otherwise it is computed from
+// FocalLength, the focal plane resolution and the image dimensions.
+func FocalLength35mm(in []byte) (f float64, err error) {
+	defer recoverCorrupt(&err)
+	var (
+		b []byte
+		t *tiff
+	)
+	if b, err = exifBlock(in); err != nil {
+		return
+	}
+	if t, err = parseTIFF(b); err != nil {
+		return
+	}
+	if v, ok := t.uint(t.exif, focalLengthIn35mmFilmTag); ok && v > 0 {
+		f = float64(v)
+		return
+	}
+	focal, ok := t.float(t.exif, focalLengthTag)
+	if !ok || focal <= 0 {
+		err = ErrTagNotFound
+		return
+	}
+	width, wok := t.float(t.exif, pixelXDimensionTag)
+	height, hok := t.float(t.exif, pixelYDimensionTag)
+	if !wok || !hok {
+		width, wok = t.float(t.ifd0, imageWidthTag)
+		height, hok = t.float(t.ifd0, imageLengthTag)
+	}
+	xres, xok := t.float(t.exif, focalPlaneXResolutionTag)
+	yres, yok := t.float(t.exif, focalPlaneYResolutionTag)
+	if !wok || !hok || !xok || !yok {
+		err = ErrTagNotFound
+		return
+	}
+	unit, ok := t.uint(t.exif, focalPlaneResolutionUnitTag)
+	if !ok {
+		unit = 2 // default unit is inch
+	}
+	if f, ok = focalLength35mm(focal, width, height, xres, yres, unit); !ok {
+		err = ErrInvalidTagValue
+	}
+	return
+}
+
+// focalLength35mm compute the 35mm-equivalent of focal from the sensor size,
+// given in pixels and pixels per resolution unit.
+func focalLength35mm(focal, width, height, xres, yres float64, unit uint32) (f float64, ok bool) {
+	var mm float64 // millimetres per resolution unit
+	switch unit {
+	case 2: // inch
+		mm = 25.4
+	case 3: // centimetre
+		mm = 10
+	case 4: // millimetre
+		mm = 1
+	case 5: // micrometre
+		mm = 0.001
+	default:
+		return
+	}
+	if width <= 0 || height <= 0 || xres <= 0 || yres <= 0 {
+		return
+	}
+	diagonal := math.Hypot(width/xres*mm, height/yres*mm)
+	f, ok = focal*fullFrameDiagonal/diagonal, true
+	return
+}
@@ -0,0 +1,105 @@
+package exif
+
+import (
+	"encoding/binary"
+	"math"
+	"testing"
+)
+
+// testTag is a tag used to build synthetic exif blocks.
+type testTag struct {
+	tag, typ uint16
+	count    uint32
+	value    []byte
+}
+
+func rational(order binary.ByteOrder, num, den uint32) []byte {
+	b := make([]byte, 8)
+	order.PutUint32(b, num)
+	order.PutUint32(b[4:], den)
+	return b
+}
+
+func short(order binary.ByteOrder, v uint16) []byte {
+	b := make([]byte, 2)
+	order.PutUint16(b, v)
+	return b
+}
+
+// testJPEG build a jpeg holding an exif APP1 with ifd0 and an exif sub IFD.
+func testJPEG(order binary.ByteOrder, ifd0, sub []testTag) []byte {
+	flag := uint16(byteOrderBE)
+	if order == binary.LittleEndian {
+		flag = byteOrderLE
+	}
+	b := make([]byte, 8)
+	binary.BigEndian.PutUint16(b, flag)
+	order.PutUint16(b[2:], byteOrderExt)
+	order.PutUint32(b[4:], 8)
+	writeIFD := func(tags []testTag, next bool) {
+		start := len(b)
+		data := start + 2 + len(tags)*12 + 4
+		var values []byte
+		b = append(b, short(order, uint16(len(tags)))...)
+		for _, t := range tags {
+			e := make([]byte, 12)
+			order.PutUint16(e, t.tag)
+			order.PutUint16(e[2:], t.typ)
+			order.PutUint32(e[4:], t.count)
+			if len(t.value) <= 4 {
+				copy(e[8:], t.value)
+			} else {
+				order.PutUint32(e[8:], uint32(data+len(values)))
+				values = append(values, t.value...)
+			}
+			b = append(b, e...)
+		}
+		n := make([]byte, 4)
+		if next {
+			order.PutUint32(n, uint32(data+len(values)))
+		}
+		b = append(b, n...)
+		b = append(b, values...)
+	}
+	ifd0 = append(ifd0, testTag{exifIFDTag, typeLong, 1, nil})
+	// the exif IFD follows ifd0, which only has inline values besides the pointer
+	p := make([]byte, 4)
+	order.PutUint32(p, uint32(8+2+len(ifd0)*12+4))
+	ifd0[len(ifd0)-1].value = p
+	writeIFD(ifd0, false)
+	writeIFD(sub, false)
+	out := []byte{0xff, 0xd8, 0xff, 0xe1, 0, 0}
+	binary.BigEndian.PutUint16(out[4:], uint16(2+len(exifPrefix)+len(b)))
+	out = append(out, exifPrefix...)
+	out = append(out, b...)
+	return append(out, 0xff, 0xd9)
+}
+
+func TestFocalLength35mm(t *testing.T) {
+	src := readFixture(t, "exif_littleEndian.jpg")
+	if f, err := FocalLength35mm(src); err != nil || f != 26 {
+		t.Fatalf("FocalLength35mm(exif_littleEndian.jpg) = %v, %v, want 26", f, err)
+	}
+	src = readFixture(t, filename)
+	if f, err := FocalLength35mm(src); err != nil || f != 32 {
+		t.Fatalf("FocalLength35mm(%s) = %v, %v, want 32", filename, f, err)
+	}
+	for _, order := range []binary.ByteOrder{binary.BigEndian, binary.LittleEndian} {
+		// 4000x3000 pixels on an 18x12mm sensor is a crop factor of 2
+		sub := []testTag{
+			{focalLengthTag, typeRational, 1, rational(order, 10, 1)},
+			{pixelXDimensionTag, typeShort, 1, short(order, 4000)},
+			{pixelYDimensionTag, typeShort, 1, short(order, 3000)},
+			{focalPlaneXResolutionTag, typeRational, 1, rational(order, 4000, 18)},
+			{focalPlaneYResolutionTag, typeRational, 1, rational(order, 3000, 12)},
+			{focalPlaneResolutionUnitTag, typeShort, 1, short(order, 4)},
+		}
+		f, err := FocalLength35mm(testJPEG(order, nil, sub))
+		if err != nil || math.Abs(f-20) > 1e-9 {
+			t.Fatalf("FocalLength35mm(%v) = %v, %v, want 20", order, f, err)
+		}
+		if _, err = FocalLength35mm(testJPEG(order, nil, sub[:3])); err != ErrTagNotFound {
+			t.Fatalf("FocalLength35mm(%v) error(%v), want %v", order, err, ErrTagNotFound)
+		}
+	}
+}
@@ -0,0 +1,167 @@
+package exif
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"io/fs"
+)
+
+// MaxImageSize is the largest image held in memory by the reader functions
+// and Batch, which streams the larger ones. Use Stream for images too large
+// to be held in memory, like motion photos.
+var MaxImageSize int64 = 1 << 30
+
+// ErrImageTooLarge is returned by the reader functions for the images larger
+// than MaxImageSize.
+var ErrImageTooLarge = errors.New("image too large")
+
+// readImage read the whole image from r, like an fs.File. in hold the first
+// MaxImageSize+1 bytes of the images larger than MaxImageSize.
+func readImage(r io.Reader) (in []byte, err error) {
+	if in, err = io.ReadAll(io.LimitReader(r, MaxImageSize+1)); err == nil && int64(len(in)) > MaxImageSize {
+		err = ErrImageTooLarge
+	}
+	return
+}
+
+// StripReader is Strip reading the image from r.
+func StripReader(r io.Reader) (out []byte, err error) {
+	defer recoverCorrupt(&err)
+	var in []byte
+	if in, err = readImage(r); err != nil {
+		return
+	}
+	return Strip(in)
+}
+
+// StripAllReader is StripAll reading the image from r.
+func StripAllReader(r io.Reader) (out []byte, err error) {
+	defer recoverCorrupt(&err)
+	var in []byte
+	if in, err = readImage(r); err != nil {
+		return
+	}
+	return StripAll(in)
+}
+
+// DecodeReader is Decode reading the image from r.
+func DecodeReader(r io.Reader) (e *Exif, err error) {
+	defer recoverCorrupt(&err)
+	var in []byte
+	if in, err = readImage(r); err != nil {
+		return
+	}
+	return Decode(in)
+}
+
+// DecodeAndPass return the exif of the image read from r while copying all
+// of r to w, so metadata can be indexed during an upload in one pass. Only
+// the segment headers and the exif are held in memory. The copy is
+// completed even if the image has no exif or a corrupt one, the decoding
+// error is then returned once r is exhausted.
+func DecodeAndPass(r io.Reader, w io.Writer) (e *Exif, err error) {
+	defer recoverCorrupt(&err)
+	br := bufio.NewReader(io.TeeReader(r, w))
+	e, derr := decodeSegments(br)
+	if _, err = io.Copy(io.Discard, br); err == nil {
+		err = derr
+	}
+	if err != nil {
+		e = nil
+	}
+	return
+}
+
+// decodeSegments read the segments of br until the exif and return it.
+func decodeSegments(br *bufio.Reader) (e *Exif, err error) {
+	var hdr [4]byte
+	if _, err = io.ReadFull(br, hdr[:2]); err != nil {
+		return
+	}
+	if binary.BigEndian.Uint16(hdr[:]) != markerSOI {
+		err = ErrMissSOIMarker
+		return
+	}
+	ff := false // the previous byte is 0xff
+	for {
+		var c byte
+		if c, err = br.ReadByte(); err != nil {
+			break
+		}
+		if c == 0xff { // marker prefix or fill byte
+			ff = true
+			continue
+		}
+		if !ff {
+			break // not a marker, like damaged image data
+		}
+		ff = false
+		marker := 0xff00 | uint16(c)
+		if marker == markerSOS || marker == markerEOI {
+			break
+		}
+		if _, err = io.ReadFull(br, hdr[2:]); err != nil {
+			return nil, truncated(err)
+		}
+		size := int(binary.BigEndian.Uint16(hdr[2:]))
+		if size < 2 {
+			err = ErrInvalidBlockSize
+			return
+		}
+		if marker != markerAPP1 {
+			if _, err = br.Discard(size - 2); err != nil {
+				return nil, truncated(err)
+			}
+			continue
+		}
+		p := make([]byte, size-2)
+		if _, err = io.ReadFull(br, p); err != nil {
+			return nil, truncated(err)
+		}
+		if bytes.HasPrefix(p, exifPrefix) {
+			return ParseTIFF(p)
+		}
+	}
+	if err == nil || err == io.EOF {
+		err = ErrNoExif
+	}
+	return
+}
+
+// truncated return ErrInvalidBlockSize for the end of file inside a segment.
+func truncated(err error) error {
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		return ErrInvalidBlockSize
+	}
+	return err
+}
+
+// StripFS return the images of fsys matching glob, see fs.Glob, with their
+// exif removed by Strip, keyed by path. Images without exif are returned
+// unchanged, other errors stop the walk.
+func StripFS(fsys fs.FS, glob string) (out map[string][]byte, err error) {
+	defer recoverCorrupt(&err)
+	var paths []string
+	if paths, err = fs.Glob(fsys, glob); err != nil {
+		return
+	}
+	out = make(map[string][]byte, len(paths))
+	for _, path := range paths {
+		var in, b []byte
+		if in, err = fs.ReadFile(fsys, path); err != nil {
+			return
+		}
+		if b, err = Strip(in); err == ErrNoExif {
+			b, err = in, nil
+		}
+		if err != nil {
+			err = &fs.PathError{Op: "strip", Path: path, Err: err}
+			return
+		}
+		out[path] = b
+	}
+	return
+}
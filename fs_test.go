@@ -0,0 +1,73 @@
+package exif
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"testing"
+	"testing/fstest"
+	"testing/iotest"
+)
+
+func TestStripFS(t *testing.T) {
+	src := readFixture(t, filename)
+	fsys := fstest.MapFS{
+		"a/exif.jpg": {Data: src},
+		"a/jfif.jpg": {Data: readFixture(t, "jfif_bigEndian.jpg")},
+		"a/note.txt": {Data: []byte("not an image")},
+	}
+	out, err := StripFS(fsys, "a/*.jpg")
+	if err != nil {
+		t.Fatalf("StripFS error(%v)", err)
+	}
+	want, _ := Strip(src)
+	if len(out) != 2 || !bytes.Equal(out["a/exif.jpg"], want) {
+		t.Fatalf("StripFS returned %d images, want 2 with a/exif.jpg stripped", len(out))
+	}
+	if _, err = StripFS(fstest.MapFS{"bad.jpg": {Data: []byte("x")}}, "*.jpg"); err == nil {
+		t.Fatalf("StripFS(bad.jpg) succeeded")
+	}
+	f, err := os.Open("testdata/" + filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if got, err := StripReader(f); err != nil || !bytes.Equal(got, want) {
+		t.Fatalf("StripReader error(%v), output differ from Strip", err)
+	}
+	s, err := StatsFS(fsys)
+	if err != nil || s.Images != 2 {
+		t.Fatalf("StatsFS = %+v, %v", s, err)
+	}
+}
+
+func TestDecodeAndPass(t *testing.T) {
+	for _, f := range corpus(t) {
+		var w bytes.Buffer
+		e, err := DecodeAndPass(iotest.OneByteReader(bytes.NewReader(f.data)), &w)
+		if !bytes.Equal(w.Bytes(), f.data) {
+			t.Fatalf("%s: copied %d bytes, want %d", f.name, w.Len(), len(f.data))
+		}
+		want, werr := Decode(f.data)
+		if (err == nil) != (werr == nil) || (werr == ErrNoExif) != (err == ErrNoExif) {
+			t.Fatalf("%s: DecodeAndPass error(%v), Decode error(%v)", f.name, err, werr)
+		}
+		if err != nil {
+			continue
+		}
+		var got, exp bytes.Buffer
+		e.WriteTo(&got)
+		want.WriteTo(&exp)
+		if !bytes.Equal(got.Bytes(), exp.Bytes()) {
+			t.Fatalf("%s: DecodeAndPass exif differ from Decode", f.name)
+		}
+	}
+	if _, err := DecodeAndPass(bytes.NewReader(readFixture(t, "exif_bigEndian.jpg")), failWriter{}); err != io.ErrClosedPipe {
+		t.Fatalf("DecodeAndPass error(%v), want the write error", err)
+	}
+}
+
+// failWriter fail all writes.
+type failWriter struct{}
+
+func (failWriter) Write([]byte) (int, error) { return 0, io.ErrClosedPipe }
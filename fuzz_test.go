@@ -0,0 +1,182 @@
+package exif
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// fuzzSeeds add the jpeg fixtures to the corpus of f.
+func fuzzSeeds(f *testing.F) {
+	names, _ := filepath.Glob("testdata/*.jpg")
+	for _, name := range names {
+		if b, err := os.ReadFile(name); err == nil && len(b) < 1<<16 {
+			f.Add(b)
+		}
+	}
+}
+
+// FuzzDecode check that decoding never panic, the corpus in
+// testdata/fuzz/FuzzDecode hold the inputs which did.
+func FuzzDecode(f *testing.F) {
+	fuzzSeeds(f)
+	f.Fuzz(fuzzDecode)
+}
+
+func fuzzDecode(t *testing.T, in []byte) {
+	e, err := Decode(in)
+	if errors.Is(err, ErrCorrupt) {
+		t.Fatalf("Decode panicked: %v", err)
+	}
+	if err != nil {
+		return
+	}
+	for range e.Tags() {
+	}
+	if _, err = e.Location(); errors.Is(err, ErrCorrupt) {
+		t.Fatalf("Location panicked: %v", err)
+	}
+	if _, err = e.Encode(Layout{KeepMakerNote: true}); errors.Is(err, ErrCorrupt) {
+		t.Fatalf("Encode panicked: %v", err)
+	}
+}
+
+// FuzzProcess check that stripping never panic.
+func FuzzProcess(f *testing.F) {
+	fuzzSeeds(f)
+	f.Fuzz(fuzzProcess)
+}
+
+func fuzzProcess(t *testing.T, in []byte) {
+	p := NewProcessor(Options{KeepOrientation: true, StripC2PA: true})
+	for name, strip := range map[string]func([]byte) ([]byte, error){"Strip": Strip, "StripAll": StripAll, "Segments": func(in []byte) ([]byte, error) {
+		_, err := Segments(in)
+		return nil, err
+	}} {
+		if _, err := strip(in); errors.Is(err, ErrCorrupt) {
+			t.Fatalf("%s panicked: %v", name, err)
+		}
+	}
+	if _, err := p.Process(in); errors.Is(err, ErrCorrupt) {
+		t.Fatalf("Process panicked: %v", err)
+	}
+	if _, err := p.NewStream(io.Discard).ReadFrom(bytes.NewReader(in)); errors.Is(err, ErrCorrupt) {
+		t.Fatalf("Stream panicked: %v", err)
+	}
+}
+
+// FuzzInspect check that the functions reading metadata never panic.
+func FuzzInspect(f *testing.F) {
+	fuzzSeeds(f)
+	f.Fuzz(fuzzInspect)
+}
+
+func fuzzInspect(t *testing.T, in []byte) {
+	for name, read := range map[string]func([]byte) error{
+		"Stats.Add": func(in []byte) error { return new(Stats).Add(in) },
+		"ContainsText": func(in []byte) error {
+			_, err := ContainsText(in, "e")
+			return err
+		},
+		"MPImages": func(in []byte) error {
+			_, err := MPImages(in)
+			return err
+		},
+	} {
+		if err := read(in); errors.Is(err, ErrCorrupt) {
+			t.Fatalf("%s panicked: %v", name, err)
+		}
+	}
+}
+
+// FuzzFeed check that streaming never panic whatever the chunk size.
+func FuzzFeed(f *testing.F) {
+	fuzzSeeds(f)
+	f.Fuzz(fuzzFeed)
+}
+
+func fuzzFeed(t *testing.T, in []byte) {
+	p := NewProcessor(Options{KeepOrientation: true, StripC2PA: true, LimitGrowth: true})
+	for _, size := range []int{1, 7, 4096} {
+		s := p.NewStream(io.Discard)
+		for i := 0; i < len(in); i += size {
+			if _, _, err := s.Feed(in[i:min(i+size, len(in))]); err != nil {
+				if errors.Is(err, ErrCorrupt) {
+					t.Fatalf("Feed(%d bytes chunks) panicked: %v", size, err)
+				}
+				break
+			}
+		}
+	}
+}
+
+// TestFuzzCorpus run every fuzz target on the seeds and recorded corpus of
+// all targets, none of which may take the ErrCorrupt path.
+func TestFuzzCorpus(t *testing.T) {
+	names, _ := filepath.Glob("testdata/*.jpg")
+	recorded, _ := filepath.Glob("testdata/fuzz/*/*")
+	var inputs [][]byte
+	for _, name := range append(names, recorded...) {
+		b, err := os.ReadFile(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if strings.HasPrefix(name, filepath.Join("testdata", "fuzz")) {
+			// go test fuzz v1 followed by a []byte("...") line
+			lines := strings.Split(strings.TrimSpace(string(b)), "\n")
+			s, err := strconv.Unquote(strings.TrimSuffix(strings.TrimPrefix(lines[len(lines)-1], "[]byte("), ")"))
+			if err != nil {
+				t.Fatalf("%s: %v", name, err)
+			}
+			b = []byte(s)
+		}
+		inputs = append(inputs, b)
+	}
+	var panics int
+	PanicHook = func(*CorruptError) { panics++ }
+	defer func() { PanicHook = nil }()
+	for _, in := range inputs {
+		for _, fuzz := range []func(*testing.T, []byte){fuzzDecode, fuzzProcess, fuzzInspect, fuzzFeed} {
+			fuzz(t, in)
+		}
+	}
+	if panics > 0 {
+		t.Fatalf("%d panics recovered on the corpus", panics)
+	}
+}
+
+func TestRecoverCorrupt(t *testing.T) {
+	var got *CorruptError
+	PanicHook = func(err *CorruptError) { got = err }
+	defer func() { PanicHook = nil }()
+	err := func() (err error) {
+		defer recoverCorrupt(&err)
+		b := readFixture(t, "empty_exif.jpg")
+		_ = b[len(b)]
+		return
+	}()
+	var ce *CorruptError
+	if !errors.Is(err, ErrCorrupt) || !errors.As(err, &ce) || ce != got || !bytes.Contains(ce.Stack, []byte("TestRecoverCorrupt")) {
+		t.Fatalf("recoverCorrupt error(%v), want a CorruptError passed to PanicHook", err)
+	}
+
+	// the panic of a handler is forwarded, not reported as corrupt input
+	got = nil
+	p := NewProcessor(Options{Handlers: []SegmentHandler{{Marker: 0xe0, Handle: func(b []byte) (SegmentAction, []byte) {
+		return SegmentAction(b[1<<20]), nil
+	}}}})
+	var v interface{}
+	func() {
+		defer func() { v = recover() }()
+		p.Process(readFixture(t, "jfif_bigEndian.jpg"))
+	}()
+	cp, ok := v.(*CallbackPanic)
+	if !ok || got != nil || !bytes.Contains(cp.Stack, []byte("TestRecoverCorrupt")) {
+		t.Fatalf("Process panic %v, PanicHook %v, want a CallbackPanic of the handler", v, got)
+	}
+}
@@ -0,0 +1,217 @@
+package exif
+
+import (
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Zone is a bounding box in decimal degrees, negative for south and west.
+// A zone with West greater than East cross the antimeridian.
+type Zone struct {
+	South, West, North, East float64
+}
+
+// Contains report whether the location g is inside z, borders included.
+func (z Zone) Contains(g GPS) bool {
+	if g.Latitude < z.South || g.Latitude > z.North {
+		return false
+	}
+	if z.West <= z.East {
+		return g.Longitude >= z.West && g.Longitude <= z.East
+	}
+	return g.Longitude >= z.West || g.Longitude <= z.East
+}
+
+// Geofence remove or fuzz the location of images taken inside its zones,
+// like around the home of the user, and keep it elsewhere. The location is
+// read from the exif gps tags and from the exif:GPSLatitude and
+// exif:GPSLongitude xmp properties, both are rewritten.
+type Geofence struct {
+	Zones []Zone
+	// Fuzz is the grid size in degrees the location is rounded to, the
+	// other gps tags and xmp properties are removed. The gps IFD and the
+	// xmp location are removed if Fuzz is 0.
+	Fuzz float64
+}
+
+// Apply return in with its location removed or fuzzed if it is inside a zone
+// of f, changed is false and out is in if the image is left untouched.
+func (f Geofence) Apply(in []byte) (out []byte, changed bool, err error) {
+	defer recoverCorrupt(&err)
+	out = in
+	e, derr := Decode(in)
+	if derr != nil && derr != ErrNoExif {
+		err = derr
+		return
+	}
+	packet, perr := xmpPacket(in)
+	if perr != nil && perr != ErrNoXMP {
+		err = perr
+		return
+	}
+	var g GPS
+	lerr := ErrTagNotFound
+	if derr == nil {
+		g, lerr = e.Location()
+	}
+	xg, xok := xmpLocation(packet)
+	if !(lerr == nil && f.inside(g)) && !(xok && f.inside(xg)) {
+		return
+	}
+	res := in
+	if lerr == nil {
+		t := e.t
+		t.layout.KeepMakerNote = true
+		if f.Fuzz > 0 {
+			t.gps = t.location(GPS{Latitude: g.Latitude, Longitude: g.Longitude}.fuzz(f.Fuzz))
+		} else {
+			t.gps = nil
+		}
+		var payload []byte
+		if payload, _, err = t.app1(); err != nil {
+			return
+		}
+		if res, err = SetRawExif(res, payload); err != nil {
+			return
+		}
+	}
+	if s := removeXMPGPS(string(packet)); s != string(packet) || xok {
+		if xok && f.Fuzz > 0 {
+			xg = xg.fuzz(f.Fuzz)
+			s = string(setXMPProperty([]byte(s), "exif:GPSLatitude", exifNamespace,
+				"<exif:GPSLatitude>"+xmpCoordinate(xg.Latitude, 'N', 'S')+"</exif:GPSLatitude>"))
+			s = string(setXMPProperty([]byte(s), "exif:GPSLongitude", exifNamespace,
+				"<exif:GPSLongitude>"+xmpCoordinate(xg.Longitude, 'E', 'W')+"</exif:GPSLongitude>"))
+		}
+		if res, err = setXMPPacket(res, []byte(s)); err != nil {
+			return
+		}
+	}
+	out, changed = res, true
+	return
+}
+
+// fuzz return g with its location rounded to a grid of grid degrees.
+func (g GPS) fuzz(grid float64) GPS {
+	g.Latitude = math.Max(-90, math.Min(90, math.Round(g.Latitude/grid)*grid))
+	g.Longitude = math.Max(-180, math.Min(180, math.Round(g.Longitude/grid)*grid))
+	return g
+}
+
+// inside report whether g is inside one of the zones of f.
+func (f Geofence) inside(g GPS) bool {
+	for _, z := range f.Zones {
+		if z.Contains(g) {
+			return true
+		}
+	}
+	return false
+}
+
+// exifNamespace is the xmp namespace of the exif properties.
+const exifNamespace = "http://ns.adobe.com/exif/1.0/"
+
+// xmpLocation return the location of the exif:GPSLatitude and
+// exif:GPSLongitude properties of the xmp packet, ok is false if it has none.
+func xmpLocation(packet []byte) (g GPS, ok bool) {
+	props, err := parseXMP(packet)
+	if err != nil || len(props["exif:GPSLatitude"]) == 0 || len(props["exif:GPSLongitude"]) == 0 {
+		return
+	}
+	lat, lok := parseXMPCoordinate(props["exif:GPSLatitude"][0], 'N', 'S')
+	lon, ook := parseXMPCoordinate(props["exif:GPSLongitude"][0], 'E', 'W')
+	if !lok || !ook || !(math.Abs(lat) <= 90) || !(math.Abs(lon) <= 180) {
+		return
+	}
+	return GPS{Latitude: lat, Longitude: lon}, true
+}
+
+// parseXMPCoordinate parse a xmp GPSCoordinate, "DDD,MM,SSk" or "DDD,MM.mmk"
+// with k the reference pos or neg, into decimal degrees.
+func parseXMPCoordinate(v string, pos, neg byte) (deg float64, ok bool) {
+	if v = strings.TrimSpace(v); len(v) < 2 {
+		return
+	}
+	ref := v[len(v)-1]
+	parts := strings.Split(v[:len(v)-1], ",")
+	if (ref != pos && ref != neg) || len(parts) < 2 || len(parts) > 3 {
+		return
+	}
+	for i, p := range parts {
+		n, err := strconv.ParseFloat(strings.TrimSpace(p), 64)
+		if err != nil || !(n >= 0) {
+			return
+		}
+		deg += n / math.Pow(60, float64(i))
+	}
+	if ref == neg {
+		deg = -deg
+	}
+	return deg, true
+}
+
+// xmpCoordinate format the decimal degrees deg as a xmp GPSCoordinate
+// "DDD,MM.mmmmk", k being pos or neg.
+func xmpCoordinate(deg float64, pos, neg byte) string {
+	ref := pos
+	if deg < 0 {
+		deg, ref = -deg, neg
+	}
+	d := math.Floor(deg)
+	return strconv.FormatFloat(d, 'f', 0, 64) + "," + strconv.FormatFloat((deg-d)*60, 'f', 4, 64) + string(ref)
+}
+
+// removeXMPGPS remove the exif:GPS properties, attributes and elements, from
+// the packet s.
+func removeXMPGPS(s string) string {
+	for from := 0; ; {
+		i := strings.Index(s[from:], "exif:GPS")
+		if i < 0 {
+			return s
+		}
+		i += from
+		n := strings.IndexAny(s[i:], " \t\r\n=/>")
+		if n < 0 {
+			return s
+		}
+		name := s[i : i+n]
+		from = i + 1
+		switch {
+		case i > 0 && s[i-1] == '<': // element
+			if r := removeXMPProperty(s, name); r != s {
+				s, from = r, i-1
+			}
+		case i > 0 && strings.ContainsRune(" \t\r\n", rune(s[i-1])): // attribute
+			if r, ok := removeXMPAttr(s, i, name); ok {
+				s, from = r, i-1
+			}
+		}
+	}
+}
+
+// removeXMPAttr remove the attribute name at offset i of s and the white
+// space before it.
+func removeXMPAttr(s string, i int, name string) (r string, ok bool) {
+	j := i + len(name)
+	for j < len(s) && strings.ContainsRune(" \t\r\n", rune(s[j])) {
+		j++
+	}
+	if j >= len(s) || s[j] != '=' {
+		return
+	}
+	for j++; j < len(s) && strings.ContainsRune(" \t\r\n", rune(s[j])); j++ {
+	}
+	if j >= len(s) || (s[j] != '"' && s[j] != '\'') {
+		return
+	}
+	k := strings.IndexByte(s[j+1:], s[j])
+	if k < 0 {
+		return
+	}
+	start := i
+	for start > 0 && strings.ContainsRune(" \t\r\n", rune(s[start-1])) {
+		start--
+	}
+	return s[:start] + s[j+1+k+1:], true
+}
@@ -0,0 +1,117 @@
+package exif
+
+import (
+	"bytes"
+	"image"
+	"math"
+	"testing"
+)
+
+func TestZoneContains(t *testing.T) {
+	fiji := Zone{South: -19, West: 177, North: -16, East: -179}
+	for _, c := range []struct {
+		z    Zone
+		g    GPS
+		want bool
+	}{
+		{Zone{38, -78, 39, -77}, GPS{Latitude: 38.88975, Longitude: -77.0089}, true},
+		{Zone{38, -78, 39, -77}, GPS{Latitude: -38.88975, Longitude: -77.0089}, false},
+		{Zone{38, -78, 39, -77}, GPS{Latitude: 38.88975, Longitude: 77.0089}, false},
+		{fiji, GPS{Latitude: -18, Longitude: 178.4}, true},
+		{fiji, GPS{Latitude: -18, Longitude: -179.5}, true},
+		{fiji, GPS{Latitude: -18, Longitude: 0}, false},
+	} {
+		if got := c.z.Contains(c.g); got != c.want {
+			t.Errorf("%v.Contains(%v) = %v, want %v", c.z, c.g, got, c.want)
+		}
+	}
+}
+
+func TestGeofence(t *testing.T) {
+	src, err := StripAll(readFixture(t, filename))
+	if err != nil {
+		t.Fatalf("StripAll error(%v)", err)
+	}
+	payload, err := Build(image.Config{Width: 640, Height: 480}, Params{
+		Orientation: 6,
+		GPS:         &GPS{Latitude: 38.88975, Longitude: -77.0089},
+	})
+	if err != nil {
+		t.Fatalf("Build error(%v)", err)
+	}
+	if src, err = InsertSegment(src, 0xe1, payload, AfterAPP0); err != nil {
+		t.Fatalf("InsertSegment error(%v)", err)
+	}
+	home := []Zone{{South: 38.8, West: -77.1, North: 39, East: -76.9}}
+	if out, changed, err := (Geofence{Zones: []Zone{{South: 0, West: 0, North: 1, East: 1}}}).Apply(src); err != nil || changed || &out[0] != &src[0] {
+		t.Fatalf("Apply outside zones = %v, %v, want unchanged", changed, err)
+	}
+	out, changed, err := Geofence{Zones: home}.Apply(src)
+	if err != nil || !changed {
+		t.Fatalf("Apply = %v, %v", changed, err)
+	}
+	e, err := Decode(out)
+	if err != nil {
+		t.Fatalf("Decode error(%v)", err)
+	}
+	if _, err = e.Location(); err != ErrTagNotFound {
+		t.Fatalf("Location error(%v), want %v", err, ErrTagNotFound)
+	}
+	if v, _ := e.t.uint(e.t.ifd0, orientationTag); v != 6 {
+		t.Fatalf("orientation = %d, want 6", v)
+	}
+	if out, _, err = (Geofence{Zones: home, Fuzz: 0.1}).Apply(src); err != nil {
+		t.Fatalf("Apply error(%v)", err)
+	}
+	if e, err = Decode(out); err != nil {
+		t.Fatalf("Decode error(%v)", err)
+	}
+	g, err := e.Location()
+	if err != nil || math.Abs(g.Latitude-38.9) > 1e-6 || math.Abs(g.Longitude+77) > 1e-6 {
+		t.Fatalf("Location = %v, %v, want 38.9, -77", g, err)
+	}
+}
+
+func TestGeofenceXMP(t *testing.T) {
+	src := readFixture(t, "xmp_gps.jpg")
+	home := []Zone{{South: 38.8, West: -77.1, North: 39, East: -76.9}}
+	out, changed, err := Geofence{Zones: home, Fuzz: 0.1}.Apply(src)
+	if err != nil || !changed {
+		t.Fatalf("Apply = %v, %v", changed, err)
+	}
+	e, err := Decode(out)
+	if err != nil {
+		t.Fatalf("Decode error(%v)", err)
+	}
+	if g, err := e.Location(); err != nil || !g.Time.IsZero() {
+		t.Fatalf("Location = %v, %v, want no time", g, err)
+	}
+	props, err := XMP(out, nil)
+	if err != nil {
+		t.Fatalf("XMP error(%v)", err)
+	}
+	if lat, lon := props["exif:GPSLatitude"], props["exif:GPSLongitude"]; len(lat) != 1 || lat[0] != "38,54.0000N" || len(lon) != 1 || lon[0] != "77,0.0000W" {
+		t.Fatalf("xmp location = %v, %v, want 38,54.0000N, 77,0.0000W", lat, lon)
+	}
+	if v := props["exif:GPSAltitude"]; v != nil {
+		t.Fatalf("exif:GPSAltitude = %v, want removed", v)
+	}
+	if v := props["dc:subject"]; len(v) != 1 || v[0] != "home" {
+		t.Fatalf("dc:subject = %v, want [home]", v)
+	}
+
+	// the xmp location alone is enough to match a zone
+	if src, err = StripAll(src); err != nil {
+		t.Fatalf("StripAll error(%v)", err)
+	}
+	if out, changed, err = (Geofence{Zones: home}).Apply(src); err != nil || !changed {
+		t.Fatalf("Apply xmp only = %v, %v", changed, err)
+	}
+	packet, err := xmpPacket(out)
+	if err != nil {
+		t.Fatalf("xmpPacket error(%v)", err)
+	}
+	if bytes.Contains(packet, []byte("exif:GPS")) {
+		t.Fatalf("packet %q keep the location", packet)
+	}
+}
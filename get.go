@@ -0,0 +1,173 @@
+package exif
+
+import (
+	"errors"
+	"math"
+	"time"
+
+	"github.com/lkzz/exif/exiftag"
+)
+
+// ErrInvalidTagType is returned by Get if the data format of the tag does not
+// convert to the requested type.
+var ErrInvalidTagType = errors.New("invalid tag type")
+
+// Value is the types a tag can be read as by Get.
+type Value interface {
+	uint16 | uint32 | int64 | float64 | string | []byte | time.Time
+}
+
+// offsetTimeDigitizedTag is the tag id of OffsetTimeDigitized.
+const offsetTimeDigitizedTag = 0x9012
+
+// offsetTags are the time zone offset tags of the date time tags.
+var offsetTags = map[exiftag.ID]uint16{
+	exiftag.DateTime:          offsetTimeTag,
+	exiftag.DateTimeOriginal:  offsetTimeOriginalTag,
+	exiftag.DateTimeDigitized: offsetTimeDigitizedTag,
+}
+
+// Get return the first component of the tag id of e converted to T:
+//
+//   - uint16 for BYTE and SHORT tags
+//   - uint32 for BYTE, SHORT and LONG tags
+//   - int64 for integer tags, signed or not
+//   - float64 for integer, rational and floating point tags
+//   - string for ASCII tags
+//   - []byte for the raw value of any tag
+//   - time.Time for ASCII date time tags, in the time zone of their offset
+//     time tag, UTC if there is none
+//
+// ErrTagNotFound is returned if e has no such tag, ErrInvalidTagType if it
+// does not convert to T.
+func Get[T Value](e *Exif, id exiftag.ID) (v T, err error) {
+	defer recoverCorrupt(&err)
+	t := e.block()
+	en, ok := t.lookupID(id)
+	if !ok {
+		err = ErrTagNotFound
+		return
+	}
+	if en.count == 0 {
+		err = ErrInvalidTagValue
+		return
+	}
+	conv := false
+	switch p := any(&v).(type) {
+	case *uint16:
+		if conv = en.typ == typeByte || en.typ == typeShort; conv {
+			*p = uint16(t.integer(en))
+		}
+	case *uint32:
+		if conv = en.typ == typeByte || en.typ == typeShort || en.typ == typeLong; conv {
+			*p = uint32(t.integer(en))
+		}
+	case *int64:
+		switch en.typ {
+		case typeByte, typeShort, typeLong, typeSByte, typeSShort, typeSLong:
+			*p, conv = t.integer(en), true
+		}
+	case *float64:
+		*p, conv = t.number(en)
+	case *string:
+		if conv = en.typ == typeASCII; conv {
+			*p = trimNUL(en.value)
+		}
+	case *[]byte:
+		*p, conv = append([]byte(nil), en.value...), true
+	case *time.Time:
+		if conv = en.typ == typeASCII; conv {
+			if *p, err = time.ParseInLocation(dateTimeLayout, trimNUL(en.value), t.zone(id)); err != nil {
+				err = ErrInvalidTagValue
+				return
+			}
+		}
+	}
+	if !conv {
+		err = ErrInvalidTagType
+	}
+	return
+}
+
+// zone return the time zone of the date time tag id, UTC if it has no offset time tag.
+func (t *tiff) zone(id exiftag.ID) *time.Location {
+	tag, ok := offsetTags[id]
+	if !ok {
+		return time.UTC
+	}
+	o, ok := lookup(t.exif, tag)
+	if !ok || o.typ != typeASCII {
+		return time.UTC
+	}
+	z, err := time.Parse(offsetTimeLayout, trimNUL(o.value))
+	if err != nil {
+		return time.UTC
+	}
+	_, off := z.Zone()
+	return time.FixedZone("", off)
+}
+
+// lookupID return the entry of id, IFD0 tags are looked up in IFD0 then in the exif IFD.
+func (t *tiff) lookupID(id exiftag.ID) (e entry, ok bool) {
+	tag := uint16(id)
+	switch id &^ 0xffff {
+	case exiftag.GPS:
+		return lookup(t.gps, tag)
+	case exiftag.Interop:
+		return lookup(t.interop, tag)
+	case 0:
+		if e, ok = lookup(t.ifd0, tag); !ok {
+			e, ok = lookup(t.exif, tag)
+		}
+	}
+	return
+}
+
+// integer return the first component of the integer entry e, sign extended.
+func (t *tiff) integer(e entry) int64 {
+	switch e.typ {
+	case typeSByte:
+		return int64(int8(e.value[0]))
+	case typeShort:
+		return int64(t.order.Uint16(e.value))
+	case typeSShort:
+		return int64(int16(t.order.Uint16(e.value)))
+	case typeLong:
+		return int64(t.order.Uint32(e.value))
+	case typeSLong:
+		return int64(int32(t.order.Uint32(e.value)))
+	}
+	return int64(e.value[0])
+}
+
+// number return the first component of the numeric entry e.
+func (t *tiff) number(e entry) (f float64, ok bool) {
+	switch e.typ {
+	case typeASCII, typeUndefined:
+		return
+	case typeRational, typeSRational:
+		num, den := t.order.Uint32(e.value), t.order.Uint32(e.value[4:])
+		if den == 0 {
+			return
+		}
+		if e.typ == typeSRational {
+			return float64(int32(num)) / float64(int32(den)), true
+		}
+		return float64(num) / float64(den), true
+	case typeFloat:
+		return float64(math.Float32frombits(t.order.Uint32(e.value))), true
+	case typeDouble:
+		return math.Float64frombits(t.order.Uint64(e.value)), true
+	}
+	return float64(t.integer(e)), true
+}
+
+// trimNUL return the ASCII value b up to its NUL terminator.
+func trimNUL(b []byte) string {
+	for i, c := range b {
+		if c == 0 {
+			return string(b[:i])
+		}
+	}
+	return string(b)
+}
@@ -0,0 +1,50 @@
+package exif
+
+import (
+	"image"
+	"testing"
+	"time"
+
+	"github.com/lkzz/exif/exiftag"
+)
+
+func TestGet(t *testing.T) {
+	when := time.Date(2020, 3, 8, 10, 4, 5, 0, time.FixedZone("", 8*3600))
+	payload, err := Build(image.Config{Width: 640, Height: 480}, Params{
+		Orientation: 6,
+		DateTime:    when,
+		Software:    "render",
+		GPS:         &GPS{Latitude: 38.88975, Longitude: -77.0089},
+	})
+	if err != nil {
+		t.Fatalf("Build error(%v)", err)
+	}
+	e, err := ParseTIFF(payload)
+	if err != nil {
+		t.Fatalf("ParseTIFF error(%v)", err)
+	}
+	if o, err := Get[uint16](e, exiftag.Orientation); err != nil || o != 6 {
+		t.Fatalf("Get Orientation = %d, %v", o, err)
+	}
+	if w, err := Get[uint32](e, exiftag.PixelXDimension); err != nil || w != 640 {
+		t.Fatalf("Get PixelXDimension = %d, %v", w, err)
+	}
+	if s, err := Get[string](e, exiftag.Software); err != nil || s != "render" {
+		t.Fatalf("Get Software = %q, %v", s, err)
+	}
+	if dt, err := Get[time.Time](e, exiftag.DateTimeOriginal); err != nil || !dt.Equal(when) {
+		t.Fatalf("Get DateTimeOriginal = %v, %v, want %v", dt, err, when)
+	}
+	if f, err := Get[float64](e, exiftag.XResolution); err != nil || f != 72 {
+		t.Fatalf("Get XResolution = %v, %v", f, err)
+	}
+	if ref, err := Get[string](e, exiftag.GPSLatitudeRef); err != nil || ref != "N" {
+		t.Fatalf("Get GPSLatitudeRef = %q, %v", ref, err)
+	}
+	if _, err := Get[uint16](e, exiftag.Software); err != ErrInvalidTagType {
+		t.Fatalf("Get[uint16] Software error(%v), want %v", err, ErrInvalidTagType)
+	}
+	if _, err := Get[int64](e, exiftag.Make); err != ErrTagNotFound {
+		t.Fatalf("Get Make error(%v), want %v", err, ErrTagNotFound)
+	}
+}
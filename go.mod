@@ -0,0 +1,3 @@
+module github.com/lkzz/exif
+
+go 1.23
@@ -0,0 +1,166 @@
+package exif
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"math"
+	"time"
+	"unicode/utf16"
+)
+
+// gps tag ids holding text
+const (
+	gpsProcessingMethodTag = 0x001b
+	gpsAreaInformationTag  = 0x001c
+)
+
+// ErrUnsupportedCharset is returned for text in the JIS character code.
+var ErrUnsupportedCharset = errors.New("unsupported character code")
+
+// character codes prefixing UNDEFINED text tags
+var (
+	charsetASCII     = []byte("ASCII\x00\x00\x00")
+	charsetJIS       = []byte("JIS\x00\x00\x00\x00\x00")
+	charsetUnicode   = []byte("UNICODE\x00")
+	charsetUndefined = make([]byte, 8)
+)
+
+// GPSProcessingMethod return the name of the method used for location finding,
+// like "GPS" or "CELLID". Some writers put a place name there.
+func (e *Exif) GPSProcessingMethod() (s string, err error) {
+	defer recoverCorrupt(&err)
+	return e.text(gpsProcessingMethodTag)
+}
+
+// GPSAreaInformation return the name of the GPS area, which is often a
+// human readable place name.
+func (e *Exif) GPSAreaInformation() (s string, err error) {
+	defer recoverCorrupt(&err)
+	return e.text(gpsAreaInformationTag)
+}
+
+// text decode the gps text tag according to its character code prefix.
+func (e *Exif) text(tag uint16) (s string, err error) {
+	t := e.block()
+	v, ok := lookup(t.gps, tag)
+	if !ok {
+		err = ErrTagNotFound
+		return
+	}
+	return t.text(v)
+}
+
+// text decode the UNDEFINED text v according to its character code prefix,
+// ASCII values are also accepted.
+func (t *tiff) text(v entry) (s string, err error) {
+	b := v.value
+	switch {
+	case v.typ == typeASCII: // written by some software instead of UNDEFINED
+	case v.typ != typeUndefined || len(b) < 8:
+		err = ErrInvalidTagValue
+		return
+	case bytes.HasPrefix(b, charsetASCII), bytes.HasPrefix(b, charsetUndefined):
+		b = b[8:]
+	case bytes.HasPrefix(b, charsetUnicode):
+		s = t.ucs2(b[8:])
+		return
+	case bytes.HasPrefix(b, charsetJIS):
+		err = ErrUnsupportedCharset
+		return
+	default:
+		err = ErrInvalidTagValue
+		return
+	}
+	s = string(bytes.TrimRight(b, "\x00 "))
+	return
+}
+
+// ucs2 decode UCS-2 text in the block byte order unless it start with a byte order mark.
+func (t *tiff) ucs2(b []byte) string {
+	order := t.order
+	if len(b) >= 2 {
+		switch {
+		case b[0] == 0xfe && b[1] == 0xff:
+			order, b = binary.BigEndian, b[2:]
+		case b[0] == 0xff && b[1] == 0xfe:
+			order, b = binary.LittleEndian, b[2:]
+		}
+	}
+	u := make([]uint16, 0, len(b)/2)
+	for i := 0; i+1 < len(b); i += 2 {
+		u = append(u, order.Uint16(b[i:]))
+	}
+	for len(u) > 0 && (u[len(u)-1] == 0 || u[len(u)-1] == ' ') {
+		u = u[:len(u)-1]
+	}
+	return string(utf16.Decode(u))
+}
+
+// Location return the gps location of e, ErrTagNotFound if it has none.
+// Time is set if e has a valid GPSTimeStamp and GPSDateStamp.
+func (e *Exif) Location() (g GPS, err error) {
+	defer recoverCorrupt(&err)
+	t := e.block()
+	lat, ok := t.degrees(gpsLatitudeTag, gpsLatitudeRefTag, 'S')
+	lon, lok := t.degrees(gpsLongitudeTag, gpsLongitudeRefTag, 'W')
+	if !ok || !lok {
+		err = ErrTagNotFound
+		return
+	}
+	if math.Abs(lat) > 90 || math.Abs(lon) > 180 {
+		err = ErrInvalidTagValue
+		return
+	}
+	g = GPS{Latitude: lat, Longitude: lon, Time: t.fixTime()}
+	return
+}
+
+// fixTime return the UTC time of GPSDateStamp and GPSTimeStamp, zero if
+// either is missing or invalid.
+func (t *tiff) fixTime() (u time.Time) {
+	d, ok := lookup(t.gps, gpsDateStampTag)
+	ts, tok := lookup(t.gps, gpsTimeStampTag)
+	if !ok || !tok || d.typ != typeASCII || ts.typ != typeRational || ts.count != 3 {
+		return
+	}
+	day, err := time.Parse(gpsDateLayout, string(bytes.TrimRight(d.value, "\x00")))
+	if err != nil {
+		return
+	}
+	var secs float64
+	for i, unit := range []float64{3600, 60, 1} {
+		num, den := t.order.Uint32(ts.value[i*8:]), t.order.Uint32(ts.value[i*8+4:])
+		if den == 0 {
+			return
+		}
+		secs += float64(num) / float64(den) * unit
+	}
+	if secs >= 24*3600 {
+		return
+	}
+	u = day.Add(time.Duration(math.Round(secs*1000)) * time.Millisecond)
+	return
+}
+
+// degrees return the degrees, minutes and seconds rationals of tag in
+// decimal degrees, negated if the first byte of refTag is neg.
+func (t *tiff) degrees(tag, refTag uint16, neg byte) (v float64, ok bool) {
+	e, ok := lookup(t.gps, tag)
+	if !ok || e.typ != typeRational || e.count != 3 {
+		ok = false
+		return
+	}
+	for i, unit := range []float64{1, 60, 3600} {
+		num, den := t.order.Uint32(e.value[i*8:]), t.order.Uint32(e.value[i*8+4:])
+		if den == 0 {
+			ok = false
+			return
+		}
+		v += float64(num) / float64(den) / unit
+	}
+	if ref, rok := lookup(t.gps, refTag); rok && len(ref.value) > 0 && ref.value[0] == neg {
+		v = -v
+	}
+	return
+}
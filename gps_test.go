@@ -0,0 +1,74 @@
+package exif
+
+import (
+	"encoding/binary"
+	"image"
+	"testing"
+	"time"
+)
+
+func TestGPSText(t *testing.T) {
+	for _, order := range []byteOrder{binary.BigEndian, binary.LittleEndian} {
+		b := &tiff{order: order}
+		ucs := append([]byte(nil), charsetUnicode...)
+		for _, r := range "Zürich" {
+			ucs = order.AppendUint16(ucs, uint16(r))
+		}
+		b.gps = []entry{
+			b.undefined(gpsProcessingMethodTag, append(append([]byte(nil), charsetASCII...), "CELLID\x00"...)),
+			b.undefined(gpsAreaInformationTag, ucs),
+		}
+		e, err := ParseTIFF(b.encode())
+		if err != nil {
+			t.Fatalf("ParseTIFF error(%v)", err)
+		}
+		if s, err := e.GPSProcessingMethod(); err != nil || s != "CELLID" {
+			t.Fatalf("GPSProcessingMethod = %q, %v", s, err)
+		}
+		if s, err := e.GPSAreaInformation(); err != nil || s != "Zürich" {
+			t.Fatalf("GPSAreaInformation = %q, %v", s, err)
+		}
+	}
+	b := &tiff{order: binary.BigEndian}
+	b.gps = []entry{b.undefined(gpsAreaInformationTag, append(append([]byte(nil), charsetJIS...), 0x30, 0x21))}
+	e, _ := ParseTIFF(b.encode())
+	if _, err := e.GPSAreaInformation(); err != ErrUnsupportedCharset {
+		t.Fatalf("GPSAreaInformation error(%v), want %v", err, ErrUnsupportedCharset)
+	}
+	if _, err := e.GPSProcessingMethod(); err != ErrTagNotFound {
+		t.Fatalf("GPSProcessingMethod error(%v), want %v", err, ErrTagNotFound)
+	}
+}
+
+func TestLocationTime(t *testing.T) {
+	fix := time.Date(2021, 12, 31, 23, 59, 58, 250e6, time.FixedZone("", -5*3600))
+	g := GPS{Latitude: 38.88975, Longitude: -77.0089, Time: fix}
+	payload, err := Build(image.Config{Width: 1, Height: 1}, Params{GPS: &g})
+	if err != nil {
+		t.Fatalf("Build error(%v)", err)
+	}
+	e, err := ParseTIFF(payload)
+	if err != nil {
+		t.Fatalf("ParseTIFF error(%v)", err)
+	}
+	if d, _ := lookup(e.t.gps, gpsDateStampTag); string(d.value) != "2022:01:01\x00" {
+		t.Fatalf("GPSDateStamp = %q, want the UTC date", d.value)
+	}
+	got, err := e.Location()
+	if err != nil {
+		t.Fatalf("Location error(%v)", err)
+	}
+	if !got.Time.Equal(fix) || got.Time.Location() != time.UTC {
+		t.Fatalf("Location Time = %v, want %v in UTC", got.Time, fix)
+	}
+	if payload, err = Build(image.Config{Width: 1, Height: 1}, Params{GPS: &GPS{Latitude: 1, Longitude: 2}}); err != nil {
+		t.Fatalf("Build error(%v)", err)
+	}
+	e, _ = ParseTIFF(payload)
+	if _, ok := lookup(e.t.gps, gpsTimeStampTag); ok {
+		t.Fatalf("GPSTimeStamp written without Time")
+	}
+	if got, _ = e.Location(); !got.Time.IsZero() {
+		t.Fatalf("Location Time = %v, want zero", got.Time)
+	}
+}
@@ -0,0 +1,68 @@
+package exif
+
+import "errors"
+
+// ErrUnknownIFD is returned for an IFDName which is not one of the IFD constants.
+var ErrUnknownIFD = errors.New("unknown IFD")
+
+// IFDName name an IFD of an exif block, like the IFD of TagID.
+type IFDName string
+
+// IFDs removed by RemoveIFD
+const (
+	// IFD0Extra is the tags of IFD0 describing the image rather than how to
+	// display it, like Make, Model, Software or Artist. Orientation,
+	// resolution and YCbCrPositioning are kept.
+	IFD0Extra  IFDName = "ifd0"
+	ExifIFD    IFDName = "exif" // also remove InteropIFD and the maker note
+	GPSIFD     IFDName = "gps"
+	InteropIFD IFDName = "interop"
+	IFD1       IFDName = "ifd1" // also remove the thumbnail
+)
+
+// displayTags are the tags of IFD0 kept by IFD0Extra.
+var displayTags = map[uint16]bool{
+	orientationTag:      true,
+	xResolutionTag:      true,
+	yResolutionTag:      true,
+	resolutionUnitTag:   true,
+	yCbCrPositioningTag: true,
+}
+
+// RemoveIFD remove the IFD which from the exif of the jpeg image in, the
+// other IFDs are kept.
+func RemoveIFD(in []byte, which IFDName) (out []byte, err error) {
+	defer recoverCorrupt(&err)
+	var e *Exif
+	if e, err = Decode(in); err != nil {
+		return
+	}
+	t := e.t
+	switch which {
+	case IFD0Extra:
+		ifd0 := t.ifd0[:0:0]
+		for _, en := range t.ifd0 {
+			if displayTags[en.tag] {
+				ifd0 = append(ifd0, en)
+			}
+		}
+		t.ifd0 = ifd0
+	case ExifIFD:
+		t.exif, t.interop = nil, nil
+	case GPSIFD:
+		t.gps = nil
+	case InteropIFD:
+		t.interop = nil
+	case IFD1:
+		t.ifd1, t.thumbnail = nil, nil
+	default:
+		err = ErrUnknownIFD
+		return
+	}
+	t.layout.KeepMakerNote = true
+	var payload []byte
+	if payload, _, err = t.app1(); err != nil {
+		return
+	}
+	return SetRawExif(in, payload)
+}
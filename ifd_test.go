@@ -0,0 +1,43 @@
+package exif
+
+import "testing"
+
+func TestRemoveIFD(t *testing.T) {
+	src := readFixture(t, filename)
+	for _, which := range []IFDName{IFD0Extra, ExifIFD, GPSIFD, InteropIFD, IFD1} {
+		out, err := RemoveIFD(src, which)
+		if err != nil {
+			t.Fatalf("RemoveIFD(%s) error(%v)", which, err)
+		}
+		e := mustDecode(t, out)
+		counts := make(map[string]int)
+		for id := range e.Tags() {
+			counts[id.IFD]++
+		}
+		switch which {
+		case IFD0Extra:
+			for id := range e.Tags() {
+				if id.IFD == "ifd0" && !displayTags[id.Tag] && id.Tag != exifIFDTag && id.Tag != gpsIFDTag {
+					t.Fatalf("RemoveIFD(%s) kept tag %#x", which, id.Tag)
+				}
+			}
+		case ExifIFD:
+			if counts["exif"]+counts["interop"] > 0 {
+				t.Fatalf("RemoveIFD(%s) kept %v", which, counts)
+			}
+		default:
+			if counts[string(which)] > 0 {
+				t.Fatalf("RemoveIFD(%s) kept %d tags", which, counts[string(which)])
+			}
+		}
+		if which != IFD1 && counts["ifd1"] == 0 {
+			t.Fatalf("RemoveIFD(%s) removed ifd1", which)
+		}
+		if which != GPSIFD && counts["gps"] == 0 {
+			t.Fatalf("RemoveIFD(%s) removed the gps IFD", which)
+		}
+	}
+	if _, err := RemoveIFD(src, "makernote"); err != ErrUnknownIFD {
+		t.Fatalf("RemoveIFD(makernote) error(%v), want ErrUnknownIFD", err)
+	}
+}
@@ -0,0 +1,115 @@
+package exif
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+)
+
+// markerAPP13 is the marker of APP13, which hold the Photoshop resources.
+const markerAPP13 = 0xffed
+
+// photoshopPrefix is the identifier at the start of an APP13 payload.
+var photoshopPrefix = []byte("Photoshop 3.0\x00")
+
+// Photoshop resource ids
+const (
+	iptcResource       = 0x0404 // IPTC IIM datasets
+	iptcDigestResource = 0x0425 // MD5 of the IPTC resource
+)
+
+// resource is a Photoshop image resource of an APP13 payload.
+type resource struct {
+	id   uint16
+	name []byte // pascal name, padded to even size
+	data []byte
+}
+
+// dataset is an IPTC IIM dataset, like 2:25 for a keyword.
+type dataset struct {
+	record, id byte
+	data       []byte
+}
+
+// parseResources parse the Photoshop resources b, ok is false if b is
+// truncated or hold something else after the resources read.
+func parseResources(b []byte) (rs []resource, ok bool) {
+	for len(b) >= 12 && string(b[:4]) == "8BIM" {
+		r := resource{id: binary.BigEndian.Uint16(b[4:])}
+		n := 6 + 1 + int(b[6])
+		n += n % 2
+		if n+4 > len(b) {
+			return
+		}
+		r.name = b[6:n]
+		size := int(binary.BigEndian.Uint32(b[n:]))
+		if b = b[n+4:]; size > len(b) {
+			return
+		}
+		r.data = b[:size]
+		if size += size % 2; size > len(b) { // data padded to even size
+			return
+		}
+		b = b[size:]
+		rs = append(rs, r)
+	}
+	ok = len(b) == 0
+	return
+}
+
+// appendResources append the Photoshop resources rs to b.
+func appendResources(b []byte, rs []resource) []byte {
+	for _, r := range rs {
+		b = append(b, "8BIM"...)
+		b = binary.BigEndian.AppendUint16(b, r.id)
+		b = append(b, r.name...)
+		b = binary.BigEndian.AppendUint32(b, uint32(len(r.data)))
+		b = append(b, r.data...)
+		if len(r.data)%2 == 1 {
+			b = append(b, 0)
+		}
+	}
+	return b
+}
+
+// parseIIM parse the IIM datasets d, ok is false if a dataset is truncated
+// or extended, which are only used for binary data.
+func parseIIM(d []byte) (ds []dataset, ok bool) {
+	for len(d) >= 5 && d[0] == 0x1c {
+		size := int(binary.BigEndian.Uint16(d[3:]))
+		if size&0x8000 != 0 || 5+size > len(d) {
+			return
+		}
+		ds = append(ds, dataset{record: d[1], id: d[2], data: d[5 : 5+size]})
+		d = d[5+size:]
+	}
+	ok = len(bytes.Trim(d, "\x00")) == 0 // some writers pad the resource
+	return
+}
+
+// appendIIM append the IIM datasets ds to b.
+func appendIIM(b []byte, ds []dataset) []byte {
+	for _, d := range ds {
+		b = append(b, 0x1c, d.record, d.id)
+		b = binary.BigEndian.AppendUint16(b, uint16(len(d.data)))
+		b = append(b, d.data...)
+	}
+	return b
+}
+
+// iptcTexts call fn with the datasets of the application record of the
+// Photoshop resources b, 2:0 being the record version.
+func iptcTexts(b []byte, fn func(TagMatch)) {
+	rs, _ := parseResources(b)
+	for _, r := range rs {
+		if r.id != iptcResource {
+			continue
+		}
+		ds, _ := parseIIM(r.data)
+		for _, d := range ds {
+			if d.record == 2 && d.id != 0 {
+				fn(TagMatch{Source: "iptc", Name: fmt.Sprintf("%d:%d", d.record, d.id), Value: string(d.data)})
+			}
+		}
+	}
+}
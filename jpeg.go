@@ -0,0 +1,70 @@
+package exif
+
+import (
+	"bytes"
+	"image"
+	"image/jpeg"
+	"io"
+)
+
+// markerAPP2 is the marker of APP2, which hold ICC profile chunks and MPF indexes.
+const markerAPP2 = 0xffe2
+
+// iccPrefix is the identifier at the start of an ICC profile APP2 payload.
+var iccPrefix = []byte("ICC_PROFILE\x00")
+
+// EncodeJPEG write m to w with jpeg.Encode, re-attaching the exif and ICC
+// profile segments of the jpeg image src, which the standard library drops.
+// The exif pixel dimensions are updated if m was resized.
+func EncodeJPEG(w io.Writer, m image.Image, o *jpeg.Options, src []byte) (err error) {
+	defer recoverCorrupt(&err)
+	var segs []segment
+	if segs, _, err = readSegments(src); err != nil {
+		return
+	}
+	var meta []byte
+	for _, s := range segs {
+		p := s.payload(src)
+		switch {
+		case s.marker == markerAPP1 && bytes.HasPrefix(p, exifPrefix):
+			meta = append(meta, resized(src[s.offset:s.offset+s.size], m.Bounds())...)
+		case s.marker == markerAPP2 && bytes.HasPrefix(p, iccPrefix):
+			meta = append(meta, src[s.offset:s.offset+s.size]...)
+		}
+	}
+	var buf bytes.Buffer
+	if err = jpeg.Encode(&buf, m, o); err != nil {
+		return
+	}
+	out := buf.Bytes()
+	// image/jpeg write no APPn segment, the metadata directly follow SOI
+	if _, err = w.Write(out[:2]); err != nil {
+		return
+	}
+	if _, err = w.Write(meta); err != nil {
+		return
+	}
+	_, err = w.Write(out[2:])
+	return
+}
+
+// resized return the exif APP1 segment seg with the pixel dimensions of r,
+// seg is returned unchanged if they already match or can not be updated.
+func resized(seg []byte, r image.Rectangle) []byte {
+	e, err := ParseTIFF(seg[4:])
+	if err != nil {
+		return seg
+	}
+	if w, h, err := e.Dimensions(); err == nil && w == r.Dx() && h == r.Dy() {
+		return seg
+	}
+	if e.SetDimensions(r.Dx(), r.Dy()) != nil {
+		return seg
+	}
+	e.t.layout.KeepMakerNote = true
+	payload, _, err := e.t.app1()
+	if err != nil {
+		return seg
+	}
+	return append([]byte{0xff, 0xe1, byte((len(payload) + 2) >> 8), byte(len(payload) + 2)}, payload...)
+}
@@ -0,0 +1,52 @@
+package exif
+
+import (
+	"bytes"
+	"image"
+	"image/jpeg"
+	"testing"
+)
+
+func TestEncodeJPEG(t *testing.T) {
+	src := readFixture(t, "jfif_bigEndian.jpg")
+	var w bytes.Buffer
+	if err := EncodeJPEG(&w, image.NewGray(image.Rect(0, 0, 16, 16)), nil, src); err != nil {
+		t.Fatalf("EncodeJPEG error(%v)", err)
+	}
+	out := w.Bytes()
+	if _, err := jpeg.Decode(bytes.NewReader(out)); err != nil {
+		t.Fatalf("jpeg.Decode error(%v)", err)
+	}
+	e, err := Decode(out)
+	if err != nil {
+		t.Fatalf("Decode error(%v), exif not re-attached", err)
+	}
+	if w, h, err := e.Dimensions(); err != nil || w != 16 || h != 16 {
+		t.Fatalf("Dimensions = %d, %d, %v, want the resized 16x16", w, h, err)
+	}
+	orig, _ := Decode(src)
+	want := orig.t.ifd0
+	for tag := range layoutTags {
+		want = remove(want, tag)
+	}
+	if got := e.t.ifd0; !sameEntries(got, want) {
+		t.Fatalf("ifd0 = %v, want %v", got, want)
+	}
+	// the exif is re-attached verbatim if the dimensions are unchanged
+	w.Reset()
+	ow, oh, _ := orig.Dimensions()
+	if err := EncodeJPEG(&w, image.NewGray(image.Rect(0, 0, ow, oh)), nil, src); err != nil {
+		t.Fatalf("EncodeJPEG error(%v)", err)
+	}
+	raw, _ := RawExif(src)
+	if got, err := RawExif(w.Bytes()); err != nil || !bytes.Equal(got, raw) {
+		t.Fatalf("RawExif error(%v), exif not re-attached verbatim", err)
+	}
+	icc, _ := findSegment(src, markerAPP2, iccPrefix)
+	if got, err := findSegment(out, markerAPP2, iccPrefix); err != nil || !bytes.Equal(got, icc) {
+		t.Fatalf("findSegment error(%v), ICC profile not re-attached", err)
+	}
+	if b, _ := xmpPacket(out); b != nil {
+		t.Fatalf("EncodeJPEG attached the xmp packet")
+	}
+}
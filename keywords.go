@@ -0,0 +1,207 @@
+package exif
+
+import (
+	"bytes"
+	"crypto/md5"
+	"encoding/binary"
+	"strings"
+	"unicode/utf16"
+)
+
+// xpKeywordsTag is the Windows keywords tag of IFD0, UCS-2 little endian
+// text separated by semicolons.
+const xpKeywordsTag = 0x9c9e
+
+// iptcKeywords is the IIM dataset 2:25 holding one keyword.
+const iptcKeywords = 25
+
+// dcNamespace is the namespace of the xmp dc:subject property.
+const dcNamespace = "http://purl.org/dc/elements/1.1/"
+
+// Keywords return the keywords of in, merged from the xmp dc:subject, the
+// IPTC Keywords and the Windows XPKeywords, in this order and without
+// duplicates.
+func Keywords(in []byte) (kw []string, err error) {
+	defer recoverCorrupt(&err)
+	var segs []segment
+	if segs, _, err = readSegments(in); err != nil {
+		return
+	}
+	seen := make(map[string]bool)
+	add := func(k string) {
+		if k = strings.TrimSpace(k); k != "" && !seen[k] {
+			seen[k] = true
+			kw = append(kw, k)
+		}
+	}
+	var xmp, iptc, xp []string
+	for _, s := range segs {
+		p := s.payload(in)
+		switch {
+		case s.marker == markerAPP1 && bytes.HasPrefix(p, xmpPrefix) && xmp == nil:
+			if props, perr := parseXMP(p[len(xmpPrefix):]); perr == nil {
+				xmp = props["dc:subject"]
+			}
+		case s.marker == markerAPP13 && bytes.HasPrefix(p, photoshopPrefix):
+			iptcTexts(p[len(photoshopPrefix):], func(m TagMatch) {
+				if m.Name == "2:25" {
+					iptc = append(iptc, m.Value)
+				}
+			})
+		case s.marker == markerAPP1 && bytes.HasPrefix(p, exifPrefix) && xp == nil:
+			if t, terr := parseTIFF(p[len(exifPrefix):]); terr == nil {
+				if e, ok := lookup(t.ifd0, xpKeywordsTag); ok {
+					xp = strings.Split(xpText(e.value), ";")
+				}
+			}
+		}
+	}
+	for _, ks := range [][]string{xmp, iptc, xp} {
+		for _, k := range ks {
+			add(k)
+		}
+	}
+	return
+}
+
+// SetKeywords return in with its keywords replaced by kw in all blocks, an
+// empty kw remove them. The xmp dc:subject is always written, the IPTC
+// Keywords and the XPKeywords only if in already has IPTC data or exif, so
+// no legacy block is created for them.
+func SetKeywords(in []byte, kw []string) (out []byte, err error) {
+	defer recoverCorrupt(&err)
+	if out, err = setIPTCKeywords(in, kw); err != nil {
+		return
+	}
+	if out, err = setXPKeywords(out, kw); err != nil {
+		return
+	}
+	packet, perr := xmpPacket(out)
+	if perr != nil && perr != ErrNoXMP {
+		err = perr
+		return
+	}
+	return setXMPPacket(out, setXMPArray(packet, "dc:subject", dcNamespace, "Bag", kw))
+}
+
+// setIPTCKeywords replace the 2:25 datasets of the IPTC resources of in,
+// the keywords are written to the first one.
+func setIPTCKeywords(in []byte, kw []string) (out []byte, err error) {
+	var segs []segment
+	if segs, _, err = readSegments(in); err != nil {
+		return
+	}
+	out = make([]byte, 0, len(in))
+	last := 0
+	for _, s := range segs {
+		p := s.payload(in)
+		if s.marker != markerAPP13 || !bytes.HasPrefix(p, photoshopPrefix) {
+			continue
+		}
+		payload, ok := iptcKeywordsPayload(p, kw)
+		if !ok {
+			continue
+		}
+		if len(payload) > 0xffff-2 {
+			err = ErrInvalidBlockSize
+			return
+		}
+		out = append(out, in[last:s.offset]...)
+		out = append(out, 0xff, 0xed, byte((len(payload)+2)>>8), byte(len(payload)+2))
+		out = append(out, payload...)
+		last, kw = s.offset+s.size, nil
+	}
+	out = append(out, in[last:]...)
+	return
+}
+
+// iptcKeywordsPayload return the APP13 payload p with its keywords replaced
+// by kw, ok is false if p has no readable IPTC resource. The IPTC digest
+// resource is updated, so readers do not flag the datasets as modified.
+func iptcKeywordsPayload(p []byte, kw []string) (payload []byte, ok bool) {
+	rs, ok := parseResources(p[len(photoshopPrefix):])
+	if !ok {
+		return
+	}
+	ok = false
+	for i, r := range rs {
+		if r.id != iptcResource {
+			continue
+		}
+		ds, iok := parseIIM(r.data)
+		if !iok {
+			return
+		}
+		var keep []dataset
+		at := 0 // the keywords follow the other datasets of record 2
+		for _, d := range ds {
+			if d.record == 2 && d.id == iptcKeywords {
+				continue
+			}
+			if keep = append(keep, d); d.record <= 2 {
+				at = len(keep)
+			}
+		}
+		news := make([]dataset, 0, len(keep)+len(kw))
+		news = append(news, keep[:at]...)
+		for _, k := range kw {
+			news = append(news, dataset{record: 2, id: iptcKeywords, data: []byte(k)})
+		}
+		news = append(news, keep[at:]...)
+		rs[i].data, ok = appendIIM(nil, news), true
+		for j := range rs {
+			if rs[j].id == iptcDigestResource {
+				sum := md5.Sum(rs[i].data)
+				rs[j].data = sum[:]
+			}
+		}
+		break
+	}
+	if ok {
+		payload = appendResources(append([]byte(nil), photoshopPrefix...), rs)
+	}
+	return
+}
+
+// setXPKeywords replace the XPKeywords tag of the exif of in, if any.
+func setXPKeywords(in []byte, kw []string) (out []byte, err error) {
+	e, err := Decode(in)
+	if err == ErrNoExif {
+		return in, nil
+	}
+	if err != nil {
+		return
+	}
+	t := e.t
+	t.layout.KeepMakerNote = true
+	_, had := lookup(t.ifd0, xpKeywordsTag)
+	if !had && len(kw) == 0 {
+		return in, nil
+	}
+	t.ifd0 = remove(t.ifd0, xpKeywordsTag)
+	if len(kw) > 0 {
+		var b []byte
+		for _, u := range utf16.Encode([]rune(strings.Join(kw, ";") + "\x00")) {
+			b = binary.LittleEndian.AppendUint16(b, u)
+		}
+		t.ifd0 = append(t.ifd0, t.bytes(xpKeywordsTag, b...))
+	}
+	var payload []byte
+	if payload, _, err = t.app1(); err != nil {
+		return
+	}
+	return SetRawExif(in, payload)
+}
+
+// xpText decode the UCS-2 little endian text of a Windows XP tag.
+func xpText(b []byte) string {
+	u := make([]uint16, 0, len(b)/2)
+	for i := 0; i+1 < len(b); i += 2 {
+		if c := binary.LittleEndian.Uint16(b[i:]); c != 0 {
+			u = append(u, c)
+		} else {
+			break
+		}
+	}
+	return string(utf16.Decode(u))
+}
@@ -0,0 +1,97 @@
+package exif
+
+import (
+	"bytes"
+	"crypto/md5"
+	"reflect"
+	"testing"
+)
+
+// findAPP13 return the payload of the first Photoshop APP13 segment of in.
+func findAPP13(t *testing.T, in []byte) []byte {
+	b, err := findSegment(in, markerAPP13, photoshopPrefix)
+	if err != nil || b == nil {
+		t.Fatalf("findSegment(APP13) = %d bytes, %v", len(b), err)
+	}
+	return append(append([]byte(nil), photoshopPrefix...), b...)
+}
+
+// testKeywordsAPP13 return an APP13 payload holding the IIM datasets ds.
+func testKeywordsAPP13(ds ...dataset) []byte {
+	r := resource{id: iptcResource, name: []byte{0, 0}, data: appendIIM(nil, ds)}
+	return appendResources(append([]byte(nil), photoshopPrefix...), []resource{r})
+}
+
+func TestKeywords(t *testing.T) {
+	in, err := InsertSegment(readFixture(t, "jfif_bigEndian.jpg"), 0xed, testKeywordsAPP13(
+		dataset{2, 0, []byte{0, 4}},
+		dataset{2, iptcKeywords, []byte("cat")},
+		dataset{2, 80, []byte("jane")},
+	), AfterAPPn)
+	if err != nil {
+		t.Fatalf("InsertSegment error(%v)", err)
+	}
+	if kw, err := Keywords(in); err != nil || !reflect.DeepEqual(kw, []string{"cat"}) {
+		t.Fatalf("Keywords = %q, %v", kw, err)
+	}
+	want := []string{"dog", "sea & sun"}
+	out, err := SetKeywords(in, want)
+	if err != nil {
+		t.Fatalf("SetKeywords error(%v)", err)
+	}
+	if kw, err := Keywords(out); err != nil || !reflect.DeepEqual(kw, want) {
+		t.Fatalf("Keywords = %q, %v, want %q", kw, err, want)
+	}
+	props, err := XMP(out, nil)
+	if err != nil {
+		t.Fatalf("XMP error(%v)", err)
+	}
+	if !reflect.DeepEqual(props["dc:subject"], want) || props["xmp:CreatorTool"][0] != "12.1.4" {
+		t.Fatalf("xmp = %q", props)
+	}
+	var iptc []string
+	matches, _ := ContainsText(out, "")
+	for _, m := range matches {
+		if m.Source == "iptc" {
+			iptc = append(iptc, m.Name+"="+m.Value)
+		}
+	}
+	if w := []string{"2:25=dog", "2:25=sea & sun", "2:80=jane"}; !reflect.DeepEqual(iptc, w) {
+		t.Fatalf("IPTC = %q, want %q", iptc, w)
+	}
+	s := findAPP13(t, out)
+	rs, _ := parseResources(s[len(photoshopPrefix):])
+	if sum := md5.Sum(rs[0].data); rs[0].id != iptcResource || rs[1].id != iptcDigestResource || !bytes.Equal(rs[1].data, sum[:]) {
+		t.Fatalf("IPTC digest %x not updated", rs[1].data)
+	}
+	e, err := Decode(out)
+	if err != nil {
+		t.Fatalf("Decode error(%v)", err)
+	}
+	if xp, _ := lookup(e.t.ifd0, xpKeywordsTag); xpText(xp.value) != "dog;sea & sun" {
+		t.Fatalf("XPKeywords = %q", xpText(xp.value))
+	}
+	if out, err = SetKeywords(out, nil); err != nil {
+		t.Fatalf("SetKeywords(nil) error(%v)", err)
+	}
+	if kw, err := Keywords(out); err != nil || kw != nil {
+		t.Fatalf("Keywords = %q, %v after removal", kw, err)
+	}
+}
+
+func TestSetKeywordsNoMetadata(t *testing.T) {
+	in, err := StripAll(readFixture(t, "exif_littleEndian.jpg"))
+	if err != nil {
+		t.Fatalf("StripAll error(%v)", err)
+	}
+	out, err := SetKeywords(in, []string{"a<b"})
+	if err != nil {
+		t.Fatalf("SetKeywords error(%v)", err)
+	}
+	if kw, err := Keywords(out); err != nil || !reflect.DeepEqual(kw, []string{"a<b"}) {
+		t.Fatalf("Keywords = %q, %v", kw, err)
+	}
+	if _, err = Decode(out); err != ErrNoExif {
+		t.Fatalf("Decode error(%v), want no exif created", err)
+	}
+}
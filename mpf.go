@@ -0,0 +1,158 @@
+package exif
+
+import (
+	"bytes"
+	"errors"
+)
+
+// mpEntryTag is the MP entry tag of the MPF index, 16 bytes per image.
+const mpEntryTag = 0xb002
+
+// mpfPrefix is the identifier at the start of an MPF APP2 payload.
+var mpfPrefix = []byte("MPF\x00")
+
+// ErrNoMPF is returned for images without MPF index of their embedded images.
+var ErrNoMPF = errors.New("no MPF index")
+
+// MP type codes of MPImage.Type
+const (
+	MPBaseline         = 0x030000 // baseline primary image
+	MPLargeThumbnail   = 0x010001 // VGA preview
+	MPLargeThumbnailHD = 0x010002 // full HD preview
+	MPPanorama         = 0x020001 // frame of a panorama
+	MPDisparity        = 0x020002 // frame of a stereo image, like a depth map
+	MPMultiAngle       = 0x020003 // frame of a multi-angle image
+)
+
+// MPImage is an image of a multi-picture file, like a frame of a burst, a
+// depth map or a preview, listed by the MPF index of the primary image.
+type MPImage struct {
+	Type   uint32 // MP type code, like MPDisparity
+	Offset int    // offset of the image in the file
+	// Data is the jpeg stream of the image, a slice of the file, which
+	// exif can be read with Decode.
+	Data []byte
+}
+
+// mpIndex is the MPF index of a file.
+type mpIndex struct {
+	t       *tiff
+	header  int // offset of the MP header, which MP entry offsets are relative to
+	entries int // offset of the first MP entry
+	n       int // number of MP entries
+}
+
+// readMPIndex return the MPF index of the primary image of in.
+func readMPIndex(in []byte) (idx mpIndex, err error) {
+	var segs []segment
+	if segs, _, err = readSegments(in); err != nil {
+		return
+	}
+	for _, s := range segs {
+		p := s.payload(in)
+		if s.marker != markerAPP2 || !bytes.HasPrefix(p, mpfPrefix) {
+			continue
+		}
+		idx.header = s.offset + 4 + len(mpfPrefix)
+		if idx.t, _, err = parseIFD0(p[len(mpfPrefix):]); err != nil {
+			return
+		}
+		e, ok := lookup(idx.t.ifd0, mpEntryTag)
+		if !ok || len(e.value)%16 != 0 || e.offset == 0 {
+			err = ErrInvalidTagValue
+			return
+		}
+		idx.entries, idx.n = idx.header+int(e.offset), len(e.value)/16
+		return
+	}
+	err = ErrNoMPF
+	return
+}
+
+// MPImages return the images listed by the MPF index of in, the primary
+// image first, so the exif of each can be decoded independently.
+func MPImages(in []byte) (images []MPImage, err error) {
+	defer recoverCorrupt(&err)
+	var idx mpIndex
+	if idx, err = readMPIndex(in); err != nil {
+		return
+	}
+	for i := 0; i < idx.n; i++ {
+		e := in[idx.entries+16*i:]
+		size, at := int64(idx.t.order.Uint32(e[4:])), int64(idx.t.order.Uint32(e[8:]))
+		if i > 0 {
+			at += int64(idx.header)
+		}
+		if size == 0 || at+size > int64(len(in)) {
+			err = ErrInvalidOffset
+			return
+		}
+		images = append(images, MPImage{
+			Type:   idx.t.order.Uint32(e) & 0xffffff,
+			Offset: int(at),
+			Data:   in[at : at+size],
+		})
+	}
+	return
+}
+
+// ReplaceMPImages return in with each image listed by its MPF index
+// replaced by fn, the primary image first, and the index updated to the new
+// sizes and offsets. The bytes between the images are kept, fn must keep
+// the MPF index of the primary image.
+func ReplaceMPImages(in []byte, fn func(i int, img []byte) ([]byte, error)) (out []byte, err error) {
+	defer recoverCorrupt(&err)
+	var images []MPImage
+	if images, err = MPImages(in); err != nil {
+		return
+	}
+	var (
+		starts = make([]int, len(images))
+		sizes  = make([]int, len(images))
+		last   int
+	)
+	out = make([]byte, 0, len(in))
+	for i, img := range images {
+		if img.Offset < last {
+			err = ErrInvalidOffset
+			return
+		}
+		var b []byte
+		if callback(func() { b, err = fn(i, img.Data) }); err != nil {
+			return
+		}
+		out = append(out, in[last:img.Offset]...)
+		starts[i], sizes[i] = len(out), len(b)
+		out = append(out, b...)
+		last = img.Offset + len(img.Data)
+	}
+	out = append(out, in[last:]...)
+	var idx mpIndex
+	if idx, err = readMPIndex(out[:sizes[0]]); err != nil {
+		return
+	}
+	if idx.n != len(images) {
+		err = ErrInvalidTagValue
+		return
+	}
+	for i := range images {
+		e := out[idx.entries+16*i:]
+		idx.t.order.PutUint32(e[4:], uint32(sizes[i]))
+		if i > 0 {
+			idx.t.order.PutUint32(e[8:], uint32(starts[i]-idx.header))
+		}
+	}
+	return
+}
+
+// StripMPImages return in with the exif of each image listed by its MPF
+// index removed like Strip, unlike StripPreviews the images shrink.
+func StripMPImages(in []byte) (out []byte, err error) {
+	defer recoverCorrupt(&err)
+	return ReplaceMPImages(in, func(_ int, img []byte) (b []byte, err error) {
+		if b, err = Strip(img); err == ErrNoExif {
+			b, err = img, nil
+		}
+		return
+	})
+}
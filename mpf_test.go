@@ -0,0 +1,91 @@
+package exif
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"testing"
+)
+
+// testMPF return primary followed by children, with an MPF index listing them.
+func testMPF(t *testing.T, primary []byte, children ...[]byte) []byte {
+	n := 1 + len(children)
+	payload := append([]byte(nil), mpfPrefix...)
+	payload = append(payload, 'M', 'M', 0, 0x2a, 0, 0, 0, 8, 0, 3)
+	payload = binary.BigEndian.AppendUint16(payload, 0xb000)
+	payload = append(payload, 0, typeUndefined, 0, 0, 0, 4, '0', '1', '0', '0')
+	payload = append(payload, 0xb0, 0x01, 0, typeLong, 0, 0, 0, 1)
+	payload = binary.BigEndian.AppendUint32(payload, uint32(n))
+	payload = append(payload, 0xb0, 0x02, 0, typeUndefined)
+	payload = binary.BigEndian.AppendUint32(payload, uint32(16*n))
+	payload = append(payload, 0, 0, 0, 50, 0, 0, 0, 0)
+	payload = append(payload, make([]byte, 16*n)...)
+	in, err := InsertSegment(primary, 0xe2, payload, AfterAPPn)
+	if err != nil {
+		t.Fatalf("InsertSegment error(%v)", err)
+	}
+	header := bytes.Index(in, mpfPrefix) + len(mpfPrefix)
+	entries := in[header+50:]
+	binary.BigEndian.PutUint32(entries, 0x20000000|MPBaseline)
+	binary.BigEndian.PutUint32(entries[4:], uint32(len(in)))
+	for i, c := range children {
+		e := entries[16*(i+1):]
+		binary.BigEndian.PutUint32(e, MPDisparity)
+		binary.BigEndian.PutUint32(e[4:], uint32(len(c)))
+		binary.BigEndian.PutUint32(e[8:], uint32(len(in)-header))
+		in = append(in, c...)
+		entries = in[header+50:]
+	}
+	return in
+}
+
+func TestMPImages(t *testing.T) {
+	payload, err := Build(image.Config{Width: 16, Height: 12}, Params{GPS: &GPS{Latitude: 38.88975, Longitude: -77.0089}})
+	if err != nil {
+		t.Fatalf("Build error(%v)", err)
+	}
+	depth, err := SetRawExif(readFixture(t, "empty_exif.jpg"), payload)
+	if err != nil {
+		t.Fatalf("SetRawExif error(%v)", err)
+	}
+	frame := readFixture(t, "jfif_bigEndian.jpg")
+	in := testMPF(t, readFixture(t, filename), depth, frame)
+	images, err := MPImages(in)
+	if err != nil || len(images) != 3 {
+		t.Fatalf("MPImages = %d images, error(%v)", len(images), err)
+	}
+	if images[0].Type != MPBaseline || images[0].Offset != 0 || images[1].Type != MPDisparity || !bytes.Equal(images[1].Data, depth) || !bytes.Equal(images[2].Data, frame) {
+		t.Fatalf("MPImages = %+v", images[:1])
+	}
+	e, err := Decode(images[1].Data)
+	if err != nil {
+		t.Fatalf("Decode(depth) error(%v)", err)
+	}
+	if _, err = e.Location(); err != nil {
+		t.Fatalf("depth Location error(%v)", err)
+	}
+
+	out, err := StripMPImages(in)
+	if err != nil {
+		t.Fatalf("StripMPImages error(%v)", err)
+	}
+	if images, err = MPImages(out); err != nil || len(images) != 3 {
+		t.Fatalf("MPImages(stripped) = %d images, error(%v)", len(images), err)
+	}
+	for i, img := range images {
+		if e, err := Decode(img.Data); err == nil {
+			if _, err = e.Location(); err == nil {
+				t.Fatalf("image %d kept its location", i)
+			}
+		}
+		if !bytes.HasPrefix(img.Data, soiPrefix) {
+			t.Fatalf("image %d is not a jpeg stream", i)
+		}
+	}
+	if len(images[0].Data)+len(images[1].Data)+len(images[2].Data) != len(out) {
+		t.Fatalf("MPImages(stripped) do not cover the %d bytes", len(out))
+	}
+	if _, err = MPImages(frame); err != ErrNoMPF {
+		t.Fatalf("MPImages(no MPF) error(%v), want ErrNoMPF", err)
+	}
+}
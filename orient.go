@@ -0,0 +1,67 @@
+package exif
+
+import (
+	"encoding/binary"
+	"errors"
+)
+
+// orientation errors
+var (
+	ErrNoFrame = errors.New("missing JPEG frame header")
+)
+
+// Transform is the operation turning the stored pixels of an image upright:
+// mirror horizontally if FlipH, then rotate clockwise by Rotate degrees.
+type Transform struct {
+	Orientation int  // exif orientation, 1 if absent or invalid
+	FlipH       bool // mirror left to right before rotating
+	Rotate      int  // clockwise rotation in degrees: 0, 90, 180 or 270
+	Width       int  // width after the transform
+	Height      int  // height after the transform
+}
+
+// transforms is the flip and rotation of each exif orientation.
+var transforms = [9]struct {
+	flip   bool
+	rotate int
+}{
+	{}, {}, {true, 0}, {false, 180}, {true, 180}, {true, 270}, {false, 90}, {true, 90}, {false, 270},
+}
+
+// OrientThenResizeHint return the transform turning the pixels of the jpeg
+// image in upright, with the dimensions once transformed, for pipelines that
+// rotate and resize the pixels themselves.
+func OrientThenResizeHint(in []byte) (tr Transform, err error) {
+	defer recoverCorrupt(&err)
+	var segs []segment
+	if segs, _, err = readSegments(in); err != nil {
+		return
+	}
+	tr.Orientation = 1
+	found := false
+	for _, s := range segs {
+		p := s.payload(in)
+		switch m := s.marker & 0xff; {
+		case isExif(in, s) && !emptyExif(p) && tr.Orientation == 1:
+			var t *tiff
+			if t, _, err = parseIFD0(p[len(exifPrefix):]); err != nil {
+				return
+			}
+			if v, ok := t.uint(t.ifd0, orientationTag); ok && v >= 1 && v <= 8 {
+				tr.Orientation = int(v)
+			}
+		case m >= 0xc0 && m <= 0xcf && m != 0xc4 && m != 0xc8 && m != 0xcc && len(p) >= 5 && !found:
+			tr.Height, tr.Width = int(binary.BigEndian.Uint16(p[1:])), int(binary.BigEndian.Uint16(p[3:]))
+			found = true
+		}
+	}
+	if !found {
+		err = ErrNoFrame
+		return
+	}
+	tr.FlipH, tr.Rotate = transforms[tr.Orientation].flip, transforms[tr.Orientation].rotate
+	if tr.Rotate == 90 || tr.Rotate == 270 {
+		tr.Width, tr.Height = tr.Height, tr.Width
+	}
+	return
+}
@@ -0,0 +1,53 @@
+package exif
+
+import (
+	"image"
+	"testing"
+)
+
+func TestOrientThenResizeHint(t *testing.T) {
+	// exif_bigEndian.jpg is 4000x3000 with orientation 6
+	tr, err := OrientThenResizeHint(readFixture(t, filename))
+	if err != nil {
+		t.Fatalf("OrientThenResizeHint error(%v)", err)
+	}
+	if want := (Transform{Orientation: 6, Rotate: 90, Width: 3000, Height: 4000}); tr != want {
+		t.Fatalf("OrientThenResizeHint = %+v, want %+v", tr, want)
+	}
+	bare, err := StripAll(readFixture(t, filename))
+	if err != nil {
+		t.Fatalf("StripAll error(%v)", err)
+	}
+	if tr, err = OrientThenResizeHint(bare); err != nil || tr != (Transform{Orientation: 1, Width: 4000, Height: 3000}) {
+		t.Fatalf("OrientThenResizeHint(no exif) = %+v, %v", tr, err)
+	}
+	for o, want := range []Transform{
+		1: {Width: 4000, Height: 3000},
+		2: {FlipH: true, Width: 4000, Height: 3000},
+		3: {Rotate: 180, Width: 4000, Height: 3000},
+		4: {FlipH: true, Rotate: 180, Width: 4000, Height: 3000},
+		5: {FlipH: true, Rotate: 270, Width: 3000, Height: 4000},
+		6: {Rotate: 90, Width: 3000, Height: 4000},
+		7: {FlipH: true, Rotate: 90, Width: 3000, Height: 4000},
+		8: {Rotate: 270, Width: 3000, Height: 4000},
+	} {
+		if o == 0 {
+			continue
+		}
+		payload, err := Build(image.Config{Width: 4000, Height: 3000}, Params{Orientation: o})
+		if err != nil {
+			t.Fatalf("Build error(%v)", err)
+		}
+		in, err := InsertSegment(bare, 0xe1, payload, AfterSOI)
+		if err != nil {
+			t.Fatalf("InsertSegment error(%v)", err)
+		}
+		want.Orientation = o
+		if tr, err = OrientThenResizeHint(in); err != nil || tr != want {
+			t.Fatalf("OrientThenResizeHint(%d) = %+v, %v, want %+v", o, tr, err, want)
+		}
+	}
+	if _, err = OrientThenResizeHint([]byte{0xff, 0xd8, 0xff, 0xd9}); err != ErrNoFrame {
+		t.Fatalf("OrientThenResizeHint(no frame) error(%v), want %v", err, ErrNoFrame)
+	}
+}
@@ -0,0 +1,105 @@
+package exif
+
+import (
+	"io"
+	"time"
+)
+
+// Patch is a change of len(Data) bytes of an image at Offset. Patches
+// edit an image in place when the new values fit the existing space,
+// instead of rewriting the whole file.
+type Patch struct {
+	Offset int64
+	Data   []byte
+}
+
+// dateTimeDigitizedTag is the tag id of DateTimeDigitized.
+const dateTimeDigitizedTag = 0x9004
+
+// dateTimeTags are the ASCII date time tags shifted by DateTimePatches.
+var dateTimeTags = []struct {
+	ifd string
+	tag uint16
+}{
+	{"ifd0", dateTimeTag},
+	{"exif", dateTimeOriginalTag},
+	{"exif", dateTimeDigitizedTag},
+}
+
+// OrientationPatches return the patches setting the orientation of the jpeg
+// image in to o, ErrTagNotFound if it has no orientation tag to overwrite.
+func OrientationPatches(in []byte, o int) (ps []Patch, err error) {
+	defer recoverCorrupt(&err)
+	if o < 1 || o > 8 {
+		err = ErrInvalidTagValue
+		return
+	}
+	var t *tiff
+	if t, err = patchable(in); err != nil {
+		return
+	}
+	e, ok := lookup(t.ifd0, orientationTag)
+	if !ok || e.typ != typeShort || e.count != 1 {
+		err = ErrTagNotFound
+		return
+	}
+	ps = append(ps, Patch{Offset: offsetIn(in, e.value), Data: t.order.AppendUint16(nil, uint16(o))})
+	return
+}
+
+// DateTimePatches return the patches shifting DateTime, DateTimeOriginal and
+// DateTimeDigitized of the jpeg image in by d, the tags absent or not holding
+// a valid date time are left untouched.
+func DateTimePatches(in []byte, d time.Duration) (ps []Patch, err error) {
+	defer recoverCorrupt(&err)
+	var t *tiff
+	if t, err = patchable(in); err != nil {
+		return
+	}
+	for _, dt := range dateTimeTags {
+		es := t.ifd0
+		if dt.ifd == "exif" {
+			es = t.exif
+		}
+		e, ok := lookup(es, dt.tag)
+		if !ok || e.typ != typeASCII || len(e.value) < len(dateTimeLayout) {
+			continue
+		}
+		v, perr := time.Parse(dateTimeLayout, string(e.value[:len(dateTimeLayout)]))
+		if perr != nil {
+			continue
+		}
+		s := v.Add(d).Format(dateTimeLayout)
+		if len(s) != len(dateTimeLayout) { // out of the four digit years
+			err = ErrInvalidTagValue
+			return
+		}
+		ps = append(ps, Patch{Offset: offsetIn(in, e.value), Data: []byte(s)})
+	}
+	return
+}
+
+// ApplyPatches write ps to w, typically the *os.File of the image.
+func ApplyPatches(w io.WriterAt, ps []Patch) (err error) {
+	defer recoverCorrupt(&err)
+	for _, p := range ps {
+		if _, err = w.WriteAt(p.Data, p.Offset); err != nil {
+			return
+		}
+	}
+	return
+}
+
+// patchable return the tiff of in, its values are slices of in.
+func patchable(in []byte) (t *tiff, err error) {
+	var b []byte
+	if b, err = exifBlock(in); err != nil {
+		return
+	}
+	return parseTIFF(b)
+}
+
+// offsetIn return the offset of b in in, which b is a slice of.
+func offsetIn(in, b []byte) int64 {
+	return int64(cap(in) - cap(b))
+}
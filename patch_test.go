@@ -0,0 +1,64 @@
+package exif
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestPatches(t *testing.T) {
+	src := readFixture(t, filename)
+	ps, err := OrientationPatches(src, 3)
+	if err != nil || len(ps) != 1 || len(ps[0].Data) != 2 {
+		t.Fatalf("OrientationPatches = %v, %v", ps, err)
+	}
+	dts, err := DateTimePatches(src, 90*time.Minute)
+	if err != nil || len(dts) == 0 {
+		t.Fatalf("DateTimePatches = %v, %v", dts, err)
+	}
+	before, _ := Decode(src)
+	path := filepath.Join(t.TempDir(), "patched.jpg")
+	if err = os.WriteFile(path, src, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.OpenFile(path, os.O_RDWR, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = ApplyPatches(f, append(ps, dts...)); err != nil {
+		t.Fatalf("ApplyPatches error(%v)", err)
+	}
+	f.Close()
+	out, _ := os.ReadFile(path)
+	if len(out) != len(src) {
+		t.Fatalf("patched size %d, want %d", len(out), len(src))
+	}
+	e, err := Decode(out)
+	if err != nil {
+		t.Fatalf("Decode error(%v)", err)
+	}
+	if v, _ := e.t.uint(e.t.ifd0, orientationTag); v != 3 {
+		t.Fatalf("orientation = %d, want 3", v)
+	}
+	for _, dt := range dateTimeTags {
+		es, old := e.t.ifd0, before.t.ifd0
+		if dt.ifd == "exif" {
+			es, old = e.t.exif, before.t.exif
+		}
+		got, ok := lookup(es, dt.tag)
+		if !ok {
+			continue
+		}
+		prev, _ := lookup(old, dt.tag)
+		a, _ := time.Parse(dateTimeLayout, string(bytes.TrimRight(prev.value, "\x00")))
+		b, _ := time.Parse(dateTimeLayout, string(bytes.TrimRight(got.value, "\x00")))
+		if b.Sub(a) != 90*time.Minute {
+			t.Fatalf("tag %#x shifted by %v, want 1h30m", dt.tag, b.Sub(a))
+		}
+	}
+	if _, err = OrientationPatches(readFixture(t, "empty_exif.jpg"), 3); err == nil {
+		t.Fatalf("OrientationPatches(empty exif) succeeded")
+	}
+}
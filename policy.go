@@ -0,0 +1,154 @@
+package exif
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/lkzz/exif/exiftag"
+)
+
+// ErrInvalidPolicy is returned for policies naming unknown tags or metadata.
+var ErrInvalidPolicy = errors.New("invalid policy")
+
+// Policy is a stripping policy read from a JSON document, so it can be
+// managed outside of compiled code and shared across services, like
+//
+//	{
+//		"keep": ["Orientation", "Copyright"],
+//		"drop": ["xmp", "iptc"],
+//		"gps_fuzz": 0.01,
+//		"formats": {"mpo": {"drop": ["xmp", "iptc", "previews"]}}
+//	}
+type Policy struct {
+	// Keep is the names of the tags kept in a minimal exif as in the exif
+	// specification, like "Orientation", "Copyright" or "GPSAltitude".
+	Keep []string `json:"keep"`
+	// Drop is the metadata removed besides the exif: "xmp", "iptc", "icc",
+	// "mpf", "c2pa", "previews", "comment" or "APP0" to "APP15".
+	Drop []string `json:"drop"`
+	// GPSFuzz keep the location rounded to a grid of GPSFuzz degrees, see
+	// Options.FuzzGPS.
+	GPSFuzz float64 `json:"gps_fuzz"`
+	// MaxGrowth limit the growth of the images, see Options.LimitGrowth.
+	MaxGrowth   *int `json:"max_growth"`
+	RelocateXMP bool `json:"relocate_xmp"`
+	Lenient     bool `json:"lenient"`
+	// Formats override the policy by image format, like "mpo", the fields
+	// set in an override replace those of the policy.
+	Formats map[string]json.RawMessage `json:"formats"`
+}
+
+// dropHandlers are the handlers of the metadata of Policy.Drop.
+var dropHandlers = map[string]SegmentHandler{
+	"xmp":     {Marker: 0xe1, Prefix: xmpPrefix, Handle: dropSegment},
+	"iptc":    {Marker: 0xed, Prefix: photoshopPrefix, Handle: dropSegment},
+	"icc":     {Marker: 0xe2, Prefix: iccPrefix, Handle: dropSegment},
+	"mpf":     {Marker: 0xe2, Prefix: mpfPrefix, Handle: dropSegment},
+	"comment": {Marker: markerCOM, Handle: dropSegment},
+}
+
+// dropSegment is the Handle of drop handlers.
+func dropSegment([]byte) (SegmentAction, []byte) {
+	return DropSegment, nil
+}
+
+// ReadPolicy read a JSON policy from r, unknown fields are rejected.
+func ReadPolicy(r io.Reader) (p *Policy, err error) {
+	defer recoverCorrupt(&err)
+	d := json.NewDecoder(r)
+	d.DisallowUnknownFields()
+	p = new(Policy)
+	if err = d.Decode(p); err != nil {
+		p = nil
+		return
+	}
+	for format := range p.Formats {
+		if _, err = p.Options(format); err != nil {
+			p = nil
+			return
+		}
+	}
+	if _, err = p.Options(""); err != nil {
+		p = nil
+	}
+	return
+}
+
+// Options return the options of p for the images of format, with the
+// override of format if any. An empty format use p alone.
+func (p *Policy) Options(format string) (o Options, err error) {
+	defer recoverCorrupt(&err)
+	q := *p
+	// the decoder reuse the backing arrays of the slices it decode into
+	q.Keep, q.Drop = append([]string(nil), p.Keep...), append([]string(nil), p.Drop...)
+	if raw, ok := p.Formats[format]; ok && format != "" {
+		d := json.NewDecoder(strings.NewReader(string(raw)))
+		d.DisallowUnknownFields()
+		if err = d.Decode(&q); err != nil {
+			err = fmt.Errorf("%w: format %s: %v", ErrInvalidPolicy, format, err)
+			return
+		}
+	}
+	ids := tagIDs()
+	for _, name := range q.Keep {
+		if name == "Orientation" {
+			o.KeepOrientation = true
+			continue
+		}
+		id, ok := ids[name]
+		if !ok {
+			err = fmt.Errorf("%w: unknown tag %q", ErrInvalidPolicy, name)
+			return
+		}
+		o.KeepTags = append(o.KeepTags, id)
+	}
+	for _, kind := range q.Drop {
+		switch h, ok := dropHandlers[kind]; {
+		case ok:
+			o.Handlers = append(o.Handlers, h)
+		case kind == "c2pa":
+			o.StripC2PA = true
+		case kind == "previews":
+			o.StripPreviews = true
+		case strings.HasPrefix(kind, "APP"):
+			n, perr := strconv.Atoi(kind[3:])
+			if perr != nil || n < 0 || n > 15 {
+				err = fmt.Errorf("%w: unknown metadata %q", ErrInvalidPolicy, kind)
+				return
+			}
+			o.Handlers = append(o.Handlers, SegmentHandler{Marker: markerAPP0 + byte(n), Handle: dropSegment})
+		default:
+			err = fmt.Errorf("%w: unknown metadata %q", ErrInvalidPolicy, kind)
+			return
+		}
+	}
+	if q.GPSFuzz < 0 {
+		err = fmt.Errorf("%w: negative gps_fuzz", ErrInvalidPolicy)
+		return
+	}
+	o.FuzzGPS = q.GPSFuzz
+	if q.MaxGrowth != nil {
+		o.LimitGrowth, o.MaxGrowth = true, *q.MaxGrowth
+	}
+	o.RelocateXMP, o.Lenient = q.RelocateXMP, q.Lenient
+	return
+}
+
+// tagIDs return the ids of the tags of IFD0 and the exif and gps IFDs by name.
+func tagIDs() map[string]exiftag.ID {
+	ids := make(map[string]exiftag.ID, len(tiffSpecs)+len(exifSpecs)+len(gpsSpecs))
+	for tag, s := range tiffSpecs {
+		ids[s.name] = exiftag.ID(tag)
+	}
+	for tag, s := range exifSpecs {
+		ids[s.name] = exiftag.ID(tag)
+	}
+	for tag, s := range gpsSpecs {
+		ids[s.name] = exiftag.GPS | exiftag.ID(tag)
+	}
+	return ids
+}
@@ -0,0 +1,77 @@
+package exif
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lkzz/exif/exiftag"
+)
+
+func TestPolicy(t *testing.T) {
+	p, err := ReadPolicy(strings.NewReader(`{
+		"keep": ["Orientation", "Model", "GPSAltitude"],
+		"drop": ["xmp", "APP9", "c2pa"],
+		"gps_fuzz": 0.5,
+		"formats": {"mpo": {"drop": ["previews"], "lenient": true}}
+	}`))
+	if err != nil {
+		t.Fatalf("ReadPolicy error(%v)", err)
+	}
+	o, err := p.Options("")
+	if err != nil {
+		t.Fatalf("Options error(%v)", err)
+	}
+	if !o.KeepOrientation || len(o.KeepTags) != 2 || o.KeepTags[0] != exiftag.Model || o.KeepTags[1] != exiftag.GPSAltitude ||
+		len(o.Handlers) != 2 || o.Handlers[1].Marker != 0xe9 || !o.StripC2PA || o.FuzzGPS != 0.5 || o.StripPreviews || o.Lenient {
+		t.Fatalf("Options = %+v", o)
+	}
+	if o, err = p.Options("mpo"); err != nil || !o.StripPreviews || !o.Lenient || len(o.Handlers) != 0 || !o.KeepOrientation {
+		t.Fatalf("Options(mpo) = %+v, error(%v)", o, err)
+	}
+
+	payload, err := Build(image.Config{Width: 16, Height: 12}, Params{GPS: &GPS{Latitude: 38.88975, Longitude: -77.0089, Time: time.Now()}})
+	if err != nil {
+		t.Fatalf("Build error(%v)", err)
+	}
+	src, _ := SetRawExif(readFixture(t, "jfif_bigEndian.jpg"), payload)
+	if src, err = Edit(src).Set(exiftag.Model, "DSC-1").Set(exiftag.Make, "Sony").Commit(); err != nil {
+		t.Fatalf("Commit error(%v)", err)
+	}
+	o, _ = p.Options("")
+	out, err := NewProcessor(o).Process(src)
+	if err != nil {
+		t.Fatalf("Process error(%v)", err)
+	}
+	e := mustDecode(t, out)
+	if got, err := Get[string](e, exiftag.Model); err != nil || got != "DSC-1" {
+		t.Fatalf("Model = %q, error(%v), want DSC-1", got, err)
+	}
+	if got, err := e.Location(); err != nil || got.Latitude != 39 || got.Longitude != -77 || !got.Time.IsZero() {
+		t.Fatalf("Location = %+v, error(%v), want 39, -77 without time", got, err)
+	}
+	if _, err = Get[string](e, exiftag.Make); err == nil {
+		t.Fatal("Make kept")
+	}
+	var streamed bytes.Buffer
+	if _, err = NewProcessor(o).NewStream(&streamed).ReadFrom(bytes.NewReader(src)); err != nil || !bytes.Equal(streamed.Bytes(), out) {
+		t.Fatalf("Stream error(%v), output differ from Process", err)
+	}
+
+	for _, doc := range []string{
+		`{"keep": ["Modle"]}`,
+		`{"drop": ["APP16"]}`,
+		`{"formats": {"heic": {"drop": ["exif"]}}}`,
+		`{"strip_all": true}`,
+	} {
+		if _, err = ReadPolicy(strings.NewReader(doc)); err == nil {
+			t.Fatalf("ReadPolicy(%s) succeeded", doc)
+		}
+	}
+	if _, err = ReadPolicy(strings.NewReader(`{"keep": ["Modle"]}`)); !errors.Is(err, ErrInvalidPolicy) {
+		t.Fatalf("ReadPolicy(unknown tag) error(%v), want ErrInvalidPolicy", err)
+	}
+}
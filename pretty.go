@@ -0,0 +1,170 @@
+package exif
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/lkzz/exif/exiftag"
+)
+
+// Formatter render tag values for display.
+type Formatter interface {
+	// Format return the display string of the tag id, ok is false to use the
+	// built-in rendering.
+	Format(id exiftag.ID, t Tag) (s string, ok bool)
+}
+
+// FormatterFunc adapt an ordinary function to Formatter.
+type FormatterFunc func(id exiftag.ID, t Tag) (string, bool)
+
+// Format call f(id, t).
+func (f FormatterFunc) Format(id exiftag.ID, t Tag) (string, bool) {
+	return f(id, t)
+}
+
+// enumerated tag values, named like exiftool does
+var prettyNames = map[exiftag.ID]map[int64]string{
+	exiftag.Orientation: {
+		1: "Horizontal (normal)", 2: "Mirror horizontal", 3: "Rotate 180", 4: "Mirror vertical",
+		5: "Mirror horizontal and rotate 270 CW", 6: "Rotate 90 CW",
+		7: "Mirror horizontal and rotate 90 CW", 8: "Rotate 270 CW",
+	},
+	exiftag.ResolutionUnit: {1: "None", 2: "inches", 3: "cm"},
+	exiftag.ExposureProgram: {
+		0: "Not Defined", 1: "Manual", 2: "Program AE", 3: "Aperture-priority AE",
+		4: "Shutter speed priority AE", 5: "Creative (Slow speed)", 6: "Action (High speed)",
+		7: "Portrait", 8: "Landscape",
+	},
+	exiftag.MeteringMode: {
+		0: "Unknown", 1: "Average", 2: "Center-weighted average", 3: "Spot",
+		4: "Multi-spot", 5: "Multi-segment", 6: "Partial", 255: "Other",
+	},
+	exiftag.ColorSpace:     {1: "sRGB", 2: "Adobe RGB", 0xffff: "Uncalibrated"},
+	exiftag.WhiteBalance:   {0: "Auto", 1: "Manual"},
+	exiftag.GPSAltitudeRef: {0: "Above Sea Level", 1: "Below Sea Level"},
+}
+
+// Pretty return the value of the tag id of e for display, like exiftool
+// render it: "1/250 s", "f/5.6", "ISO 200" or "38°53'23.1\"N". f is
+// consulted first if not nil, so UIs can localize or override the
+// rendering of some tags.
+func Pretty(e *Exif, id exiftag.ID, f Formatter) (s string, err error) {
+	defer recoverCorrupt(&err)
+	t := e.block()
+	en, ok := t.lookupID(id)
+	if !ok {
+		err = ErrTagNotFound
+		return
+	}
+	if f != nil {
+		tag := Tag{Type: en.typ, Count: en.count, Value: en.value, Order: t.order}
+		if callback(func() { s, ok = f.Format(id, tag) }); ok {
+			return
+		}
+	}
+	if en.count == 0 {
+		err = ErrInvalidTagValue
+		return
+	}
+	return t.pretty(id, en), nil
+}
+
+// pretty is the built-in rendering of Pretty.
+func (t *tiff) pretty(id exiftag.ID, e entry) string {
+	v, isNumber := t.number(e)
+	switch {
+	case id == exiftag.GPSLatitude || id == exiftag.GPSLongitude:
+		ref, refs := uint16(gpsLatitudeRefTag), "NS"
+		if id == exiftag.GPSLongitude {
+			ref, refs = gpsLongitudeRefTag, "EW"
+		}
+		if deg, ok := t.degrees(uint16(id), ref, refs[1]); ok {
+			return dmsString(deg, refs)
+		}
+	case !isNumber:
+	case id == exiftag.ExposureTime:
+		if v > 0 && v < 0.25 {
+			return fmt.Sprintf("1/%s s", trimFloat(math.Round(1/v*10)/10))
+		}
+		return trimFloat(v) + " s"
+	case id == exiftag.FNumber:
+		return fmt.Sprintf("f/%.1f", v)
+	case id == exiftag.PhotographicSensitivity:
+		return "ISO " + trimFloat(v)
+	case id == exiftag.FocalLength:
+		return fmt.Sprintf("%.1f mm", v)
+	case id == exiftag.ExposureBiasValue:
+		if v == 0 {
+			return "0"
+		}
+		return fmt.Sprintf("%+.1f", v)
+	case id == exiftag.GPSAltitude:
+		if ref, ok := t.lookupID(exiftag.GPSAltitudeRef); ok && len(ref.value) > 0 && ref.value[0] == 1 {
+			v = -v
+		}
+		return fmt.Sprintf("%.1f m", v)
+	case id == exiftag.Flash:
+		if int64(v)&1 != 0 {
+			return "Fired"
+		}
+		return "No Flash"
+	case prettyNames[id] != nil:
+		if name, ok := prettyNames[id][int64(v)]; ok {
+			return name
+		}
+	}
+	return t.plain(e)
+}
+
+// plain render e without knowledge of its tag: text, numbers separated by
+// spaces, or the size of binary data.
+func (t *tiff) plain(e entry) string {
+	switch e.typ {
+	case typeASCII:
+		return trimNUL(e.value)
+	case typeUndefined:
+		if isPrintable(e.value) {
+			return string(e.value) // like the version tags
+		}
+		return fmt.Sprintf("(Binary data %d bytes)", len(e.value))
+	}
+	size := typeSize[e.typ]
+	vs := make([]string, 0, e.count)
+	for i := 0; i < int(e.count); i++ {
+		c := e
+		c.value = e.value[i*size:]
+		if v, ok := t.number(c); ok {
+			vs = append(vs, trimFloat(v))
+		}
+	}
+	return strings.Join(vs, " ")
+}
+
+// dmsString render the decimal degrees deg with refs[0] as positive
+// reference, like 38°53'23.1"N.
+func dmsString(deg float64, refs string) string {
+	ref := refs[0]
+	if deg < 0 {
+		ref, deg = refs[1], -deg
+	}
+	tenths := int64(math.Round(deg * 36000)) // tenths of arc seconds
+	return fmt.Sprintf("%d°%d'%d.%d\"%c", tenths/36000, tenths%36000/600, tenths%600/10, tenths%10, ref)
+}
+
+// trimFloat format v with at most 10 significant digits and no trailing zeros.
+func trimFloat(v float64) string {
+	return strconv.FormatFloat(v, 'g', 10, 64)
+}
+
+// isPrintable report whether b is printable ASCII, ignoring trailing NULs.
+func isPrintable(b []byte) bool {
+	b = []byte(trimNUL(b))
+	for _, c := range b {
+		if c < 0x20 || c > 0x7e {
+			return false
+		}
+	}
+	return len(b) > 0
+}
@@ -0,0 +1,72 @@
+package exif
+
+import (
+	"image"
+	"testing"
+
+	"github.com/lkzz/exif/exiftag"
+)
+
+func TestPretty(t *testing.T) {
+	payload, err := Build(image.Config{Width: 64, Height: 48}, Params{
+		Orientation: 6,
+		GPS:         &GPS{Latitude: 38.88975, Longitude: -77.0089},
+	})
+	if err != nil {
+		t.Fatalf("Build error(%v)", err)
+	}
+	in, err := SetRawExif(readFixture(t, "empty_exif.jpg"), payload)
+	if err != nil {
+		t.Fatalf("SetRawExif error(%v)", err)
+	}
+	if in, err = Edit(in).
+		Set(exiftag.ExposureTime, 0.004).
+		Set(exiftag.FNumber, 5.6).
+		Set(exiftag.PhotographicSensitivity, 200).
+		Set(exiftag.FocalLength, 50.0).
+		Set(exiftag.ExposureBiasValue, -0.7).
+		Set(exiftag.Flash, 0x19).
+		Set(exiftag.GPSAltitudeRef, []byte{1}).
+		Set(exiftag.GPSAltitude, 12.5).
+		Commit(); err != nil {
+		t.Fatalf("Commit error(%v)", err)
+	}
+	e, err := Decode(in)
+	if err != nil {
+		t.Fatalf("Decode error(%v)", err)
+	}
+	for id, want := range map[exiftag.ID]string{
+		exiftag.ExposureTime:            "1/250 s",
+		exiftag.FNumber:                 "f/5.6",
+		exiftag.PhotographicSensitivity: "ISO 200",
+		exiftag.FocalLength:             "50.0 mm",
+		exiftag.ExposureBiasValue:       "-0.7",
+		exiftag.Flash:                   "Fired",
+		exiftag.GPSLatitude:             `38°53'23.1"N`,
+		exiftag.GPSLongitude:            `77°0'32.0"W`,
+		exiftag.GPSAltitude:             "-12.5 m",
+		exiftag.Orientation:             "Rotate 90 CW",
+		exiftag.ExifVersion:             "0232",
+		exiftag.XResolution:             "72",
+		exiftag.ComponentsConfiguration: "(Binary data 4 bytes)",
+	} {
+		if s, err := Pretty(e, id, nil); err != nil || s != want {
+			t.Errorf("Pretty(%#x) = %q, %v, want %q", uint32(id), s, err, want)
+		}
+	}
+	french := FormatterFunc(func(id exiftag.ID, tag Tag) (string, bool) {
+		if id == exiftag.Orientation {
+			return "Rotation de 90° horaire", true
+		}
+		return "", false
+	})
+	if s, _ := Pretty(e, exiftag.Orientation, french); s != "Rotation de 90° horaire" {
+		t.Fatalf("Pretty with formatter = %q", s)
+	}
+	if s, _ := Pretty(e, exiftag.FNumber, french); s != "f/5.6" {
+		t.Fatalf("Pretty fallback = %q", s)
+	}
+	if _, err = Pretty(e, exiftag.Artist, nil); err != ErrTagNotFound {
+		t.Fatalf("Pretty(Artist) error(%v), want ErrTagNotFound", err)
+	}
+}
@@ -0,0 +1,58 @@
+package exif
+
+import "bytes"
+
+// soiPrefix is the start of an embedded jpeg stream, SOI followed by a marker.
+var soiPrefix = []byte{0xff, 0xd8, 0xff}
+
+// imageEnd return the offset following the EOI of the image data starting at
+// sos, or -1 if there is none. Markers can not occur in entropy coded data,
+// where 0xff bytes are stuffed or start a restart marker.
+func imageEnd(in []byte, sos int) int {
+	for at := sos; ; {
+		i := bytes.IndexByte(in[at:], 0xff)
+		if i < 0 || at+i+1 >= len(in) {
+			return -1
+		}
+		if at += i + 1; in[at] == 0xd9 {
+			return at + 1
+		}
+	}
+}
+
+// previews return the offsets of the jpeg images appended after the image
+// whose data start at sos, like the large previews of cameras.
+func previews(in []byte, sos int) (offsets []int) {
+	at := imageEnd(in, sos)
+	for at >= 0 && bytes.HasPrefix(in[at:], soiPrefix) {
+		offsets = append(offsets, at)
+		_, end, err := readSegments(in[at:])
+		if err != nil {
+			return
+		}
+		at = imageEnd(in, at+end)
+	}
+	return
+}
+
+// previewEdits append to edits the blanking of the exif of the previews of
+// in. The exif segments keep their size, so the offsets into the file, like
+// those of the MPF index, stay valid.
+func previewEdits(in []byte, sos int, edits []edit) []edit {
+	for _, at := range previews(in, sos) {
+		segs, _, err := readSegments(in[at:])
+		if err != nil {
+			continue
+		}
+		for _, s := range segs {
+			if !isExif(in[at:], s) || emptyExif(s.payload(in[at:])) {
+				continue
+			}
+			s.offset += at
+			repl := make([]byte, s.size)
+			copy(repl, in[s.offset:s.offset+4+len(exifPrefix)])
+			edits = append(edits, edit{seg: s, repl: repl})
+		}
+	}
+	return edits
+}
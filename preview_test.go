@@ -0,0 +1,63 @@
+package exif
+
+import (
+	"bytes"
+	"image"
+	"testing"
+)
+
+func TestProcessorStripPreviews(t *testing.T) {
+	payload, err := Build(image.Config{Width: 16, Height: 12}, Params{GPS: &GPS{Latitude: 38.88975, Longitude: -77.0089}})
+	if err != nil {
+		t.Fatalf("Build error(%v)", err)
+	}
+	preview, err := SetRawExif(readFixture(t, "empty_exif.jpg"), payload)
+	if err != nil {
+		t.Fatalf("SetRawExif error(%v)", err)
+	}
+	main := readFixture(t, "jfif_bigEndian.jpg")
+	in := append(append(append([]byte(nil), main...), preview...), preview...)
+	if got := previews(in, bytes.Index(in, []byte{0xff, 0xda})); len(got) != 2 || got[0] != len(main) || got[1] != len(main)+len(preview) {
+		t.Fatalf("previews = %v, want at %d and %d", got, len(main), len(main)+len(preview))
+	}
+	out, err := NewProcessor(Options{}).Process(in)
+	if err != nil {
+		t.Fatalf("Process error(%v)", err)
+	}
+	if !bytes.HasSuffix(out, append(append([]byte(nil), preview...), preview...)) {
+		t.Fatalf("previews modified without StripPreviews")
+	}
+	if out, err = NewProcessor(Options{StripPreviews: true}).Process(in); err != nil {
+		t.Fatalf("Process error(%v)", err)
+	}
+	stripped, _ := NewProcessor(Options{}).Process(main)
+	if !bytes.HasPrefix(out, stripped) || len(out) != len(stripped)+2*len(preview) {
+		t.Fatalf("Process = %d bytes, want the stripped image followed by the previews", len(out))
+	}
+	for i, p := 0, out[len(stripped):]; i < 2; i, p = i+1, p[len(preview):] {
+		if _, err = Decode(p[:len(preview)]); err == nil {
+			t.Fatalf("preview %d still has exif", i)
+		}
+		if b, _ := findSegment(p[:len(preview)], markerAPP1, exifPrefix); !emptyExif(append(append([]byte(nil), exifPrefix...), b...)) {
+			t.Fatalf("preview %d exif not zeroed", i)
+		}
+	}
+	// an image whose only exif is in a preview is processed too
+	bare, _ := StripAll(main)
+	if out, err = NewProcessor(Options{StripPreviews: true}).Process(append(bare, preview...)); err != nil || len(out) != len(bare)+len(preview) {
+		t.Fatalf("Process = %d bytes, %v", len(out), err)
+	}
+	// the cache only remember the exif of the image itself
+	c := NewLRU(8)
+	in = append(bare, preview...)
+	k := Key{1}
+	if _, err = NewProcessor(Options{StripPreviews: true, Cache: c}).ProcessKey(in, k); err != nil {
+		t.Fatalf("ProcessKey error(%v)", err)
+	}
+	if has, _ := NewProcessor(Options{Cache: c}).HasExifKey(in, k); has {
+		t.Fatalf("HasExifKey = true for an image whose only exif is in a preview")
+	}
+	if out, err = NewProcessor(Options{StripPreviews: true, Cache: c}).ProcessKey(in, k); err != nil || bytes.Contains(out, payload[len(exifPrefix):]) {
+		t.Fatalf("ProcessKey error(%v), cached preview not stripped", err)
+	}
+}
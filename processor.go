@@ -0,0 +1,426 @@
+package exif
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"sync"
+
+	"github.com/lkzz/exif/exiftag"
+)
+
+// ErrGrowthExceeded is returned with LimitGrowth for the images still growing
+// by more than MaxGrowth bytes without the optional segments, like those whose
+// segments are enlarged by a handler.
+var ErrGrowthExceeded = errors.New("output growth exceed MaxGrowth")
+
+// Options configure a Processor.
+type Options struct {
+	// KeepOrientation keep the orientation tag in a minimal exif, like Strip.
+	KeepOrientation bool
+	// KeepTags also keep these tags of IFD0 and the exif and gps IFDs in the
+	// minimal exif, like exiftag.Copyright.
+	KeepTags []exiftag.ID
+	// FuzzGPS keep the location rounded to a grid of FuzzGPS degrees, like
+	// Geofence.Fuzz, in the minimal exif. It replace the latitude and
+	// longitude kept by KeepTags.
+	FuzzGPS float64
+	// StripC2PA also remove C2PA manifests, which are kept by default.
+	StripC2PA bool
+	// LimitGrowth guarantee the output is at most MaxGrowth bytes larger
+	// than the input, the optional segments the processor would write, like
+	// the orientation kept by KeepOrientation, are dropped to comply. The
+	// image fail with ErrGrowthExceeded if that is not enough.
+	LimitGrowth bool
+	MaxGrowth   int
+	// RelocateXMP move the XMP segments right after SOI, or after a leading
+	// JFIF APP0, where some readers expect metadata. It is ignored by Stream,
+	// which already forwarded the bytes before them.
+	RelocateXMP bool
+	// Lenient work around corrupt images instead of failing, an image
+	// without exif is returned unchanged. ProcessWarnings report what was
+	// skipped or guessed.
+	Lenient bool
+	// Handlers decide what to do with the other APPn segments they match,
+	// which are kept by default. The first matching handler is used. A
+	// panic of a handler is forwarded as a *CallbackPanic, not returned as
+	// ErrCorrupt; the same go for UniqueID, Cache and Archive.
+	Handlers []SegmentHandler
+	// StripPreviews also remove the exif of the jpeg previews appended after
+	// the image, where the location would otherwise leak. Their exif is
+	// zeroed in place, so the offsets of the MPF index stay valid. It is
+	// ignored by Stream, which forward the image data verbatim.
+	StripPreviews bool
+	// UniqueID return the ImageUniqueID written to the exif kept for the
+	// image in, like ContentID or RandomID, so downstream systems can track
+	// images across re-encodes. An existing ImageUniqueID is kept. It is
+	// ignored by Stream, which does not hold the whole image.
+	UniqueID func(in []byte) string
+	// Cache remember which images have exif by the Key given to ProcessKey
+	// and HasExifKey, images known to have none are returned without being
+	// parsed, unless StripPreviews. Keys are never computed from the image,
+	// hashing it cost more than parsing its segments. Nil disable caching.
+	Cache Cache
+	// Archive keep the segments removed from each image before it is
+	// output, keyed by the hash of the output, the image fail if Archive
+	// does. Relocated XMP segments are not removed. It is ignored by Stream,
+	// which output the image before the hash is known.
+	Archive Archiver
+}
+
+// Warning describe a problem of an image which was worked around.
+type Warning struct {
+	Offset int // offset in the input
+	Msg    string
+}
+
+// String return the warning like "at 1024: segment 0xffe1 truncated, 10 bytes missing".
+func (w Warning) String() string {
+	return fmt.Sprintf("at %d: %s", w.Offset, w.Msg)
+}
+
+// SegmentAction is what a SegmentHandler do with a segment.
+type SegmentAction int
+
+// segment handler actions
+const (
+	KeepSegment      SegmentAction = iota // keep the segment unchanged
+	DropSegment                           // remove the segment
+	TransformSegment                      // replace the segment payload
+)
+
+// SegmentHandler handle in-house APPn segments, like APP9 payloads
+// starting with "MYCO\x00". Exif APP1 segments are never passed to handlers.
+type SegmentHandler struct {
+	Marker byte   // APPn marker, like 0xe9 for APP9
+	Prefix []byte // identifier at the start of the payload
+	// Handle return the action for payload, and the new payload for TransformSegment.
+	Handle func(payload []byte) (action SegmentAction, out []byte)
+}
+
+// Processor remove the exif of images with the options it was created with,
+// reusing its scratch buffers across calls. It is safe for concurrent use.
+type Processor struct {
+	opts Options
+	pool sync.Pool // *scratch
+}
+
+// scratch is the state of a call reused through the pool.
+type scratch struct {
+	segs  []segment
+	edits []edit
+}
+
+// edit replace the segment seg of the input by repl, or remove it if repl is nil.
+type edit struct {
+	seg      segment
+	repl     []byte
+	optional bool // repl may be dropped to limit growth
+}
+
+// NewProcessor return a Processor configured with o.
+func NewProcessor(o Options) *Processor {
+	return &Processor{
+		opts: o,
+		pool: sync.Pool{New: func() interface{} { return new(scratch) }},
+	}
+}
+
+// Process return in with its exif removed.
+func (p *Processor) Process(in []byte) (out []byte, err error) {
+	defer recoverCorrupt(&err)
+	return p.Append(nil, in)
+}
+
+// ProcessKey is Process consulting the Cache of p under k, a key of in the
+// caller already has, like the content hash computed on upload.
+func (p *Processor) ProcessKey(in []byte, k Key) (out []byte, err error) {
+	defer recoverCorrupt(&err)
+	err = p.process(in, &k, func(b []byte) error {
+		out = append(out, b...)
+		return nil
+	}, nil)
+	return
+}
+
+// ProcessWarnings is Process also returning the problems of in which were
+// worked around, so suspect images can be flagged for review.
+func (p *Processor) ProcessWarnings(in []byte) (out []byte, warnings []Warning, err error) {
+	defer recoverCorrupt(&err)
+	err = p.process(in, nil, func(b []byte) error {
+		out = append(out, b...)
+		return nil
+	}, func(w Warning) {
+		warnings = append(warnings, w)
+	})
+	return
+}
+
+// Append append in with its exif removed to dst and return the extended buffer.
+func (p *Processor) Append(dst, in []byte) (out []byte, err error) {
+	defer recoverCorrupt(&err)
+	out = dst
+	err = p.process(in, nil, func(b []byte) error {
+		out = append(out, b...)
+		return nil
+	}, nil)
+	return
+}
+
+// Copy write in with its exif removed to w, without buffering the output.
+func (p *Processor) Copy(w io.Writer, in []byte) (n int64, err error) {
+	defer recoverCorrupt(&err)
+	err = p.process(in, nil, func(b []byte) error {
+		m, err := w.Write(b)
+		n += int64(m)
+		return err
+	}, nil)
+	return
+}
+
+// CopyHash is Copy also writing the output to h as it is produced, so the
+// digest of the stripped image is computed in the same pass. w may be nil
+// to only hash the output.
+func (p *Processor) CopyHash(w io.Writer, in []byte, h hash.Hash) (n int64, err error) {
+	defer recoverCorrupt(&err)
+	err = p.process(in, nil, func(b []byte) error {
+		h.Write(b) // never return an error
+		if w == nil {
+			n += int64(len(b))
+			return nil
+		}
+		m, err := w.Write(b)
+		n += int64(m)
+		return err
+	}, nil)
+	return
+}
+
+// process call emit with the successive pieces of the output, it return
+// ErrNoExif without calling emit if in has no exif, unless lenient. The
+// cache is consulted under k if not nil. warn is called with the problems
+// worked around if not nil.
+func (p *Processor) process(in []byte, k *Key, emit func([]byte) error, warn func(Warning)) (err error) {
+	if warn == nil {
+		warn = func(Warning) {}
+	}
+	cache := p.opts.Cache
+	if k == nil {
+		cache = nil
+	}
+	if cache != nil {
+		// the previews are not cached, their exif is searched whatever the answer
+		var has, ok bool
+		callback(func() { has, ok = cache.Get(*k) })
+		if ok && !has && !p.opts.StripPreviews {
+			return p.noExif(in, emit, warn)
+		}
+	}
+	sc := p.pool.Get().(*scratch)
+	defer p.pool.Put(sc)
+	var scan func(Warning)
+	if p.opts.Lenient {
+		scan = warn
+	}
+	var sos int
+	if sc.segs, sos, err = scanSegments(sc.segs[:0], in, scan); err != nil {
+		return
+	}
+	found := false
+	for _, s := range sc.segs {
+		if isExif(in, s) {
+			found = true
+			break
+		}
+	}
+	if cache != nil {
+		callback(func() { cache.Put(*k, found) })
+	}
+	var blanked []edit
+	if p.opts.StripPreviews {
+		blanked = previewEdits(in, sos, nil)
+	}
+	if !found && len(blanked) == 0 {
+		return p.noExif(in, emit, warn)
+	}
+	var boxes map[uint16]bool
+	if p.opts.StripC2PA {
+		boxes = c2paBoxes(in, sc.segs)
+	}
+	sc.edits = sc.edits[:0]
+	kept := false
+	for _, s := range sc.segs {
+		switch {
+		case p.opts.RelocateXMP && isXMP(in, s):
+			sc.edits = append(sc.edits, edit{seg: s})
+		case isExif(in, s):
+			e := edit{seg: s}
+			b := s.payload(in)
+			orientation := p.opts.KeepOrientation && !emptyExif(b)
+			if !kept && (p.opts.keeps() || p.opts.UniqueID != nil) {
+				var id func() string
+				if p.opts.UniqueID != nil {
+					id = func() (s string) {
+						callback(func() { s = p.opts.UniqueID(in) })
+						return
+					}
+				}
+				var oriented bool
+				e.repl, oriented = keptSegment(b, &p.opts, id)
+				e.optional, kept = true, true
+				if orientation && !oriented {
+					warn(Warning{Offset: s.offset, Msg: "no readable orientation, removed"})
+				}
+			}
+			sc.edits = append(sc.edits, e)
+		case s.marker == markerAPP11 && inBoxes(boxes, s.payload(in)):
+			sc.edits = append(sc.edits, edit{seg: s})
+		default:
+			e, ok, herr := p.handle(in, s)
+			if herr != nil {
+				err = herr
+				return
+			}
+			if ok {
+				sc.edits = append(sc.edits, e)
+			}
+		}
+	}
+	if p.opts.RelocateXMP {
+		sc.edits = p.relocateXMP(in, sc.segs, sc.edits)
+	}
+	sc.edits = append(sc.edits, blanked...)
+	if p.opts.LimitGrowth {
+		if err = p.limitGrowth(sc.edits); err != nil {
+			return
+		}
+	}
+	if p.opts.Archive != nil {
+		if err = p.archive(in, sc.edits); err != nil {
+			return
+		}
+	}
+	last := 0
+	for _, e := range sc.edits {
+		if err = emit(in[last:e.seg.offset]); err != nil {
+			return
+		}
+		if len(e.repl) > 0 {
+			if err = emit(e.repl); err != nil {
+				return
+			}
+		}
+		last = e.seg.offset + e.seg.size
+	}
+	return emit(in[last:])
+}
+
+// noExif return ErrNoExif, or emit in unchanged if lenient.
+func (p *Processor) noExif(in []byte, emit func([]byte) error, warn func(Warning)) error {
+	if !p.opts.Lenient {
+		return ErrNoExif
+	}
+	warn(Warning{Offset: 0, Msg: "no exif, image returned unchanged"})
+	return emit(in)
+}
+
+// relocateXMP return edits, sorted by offset, with the insertion of the XMP
+// segments of in after SOI or APP0, they are removed from their place by
+// process. If a handler replaced the leading APP0, they follow its
+// replacement.
+func (p *Processor) relocateXMP(in []byte, segs []segment, edits []edit) []edit {
+	var xmp []byte
+	for _, s := range segs {
+		if isXMP(in, s) {
+			xmp = append(xmp, in[s.offset:s.offset+s.size]...)
+		}
+	}
+	if xmp == nil {
+		return edits
+	}
+	at := 2
+	if len(segs) > 0 && isJFIF(in, segs[0]) {
+		if at = segs[0].offset + segs[0].size; len(edits) > 0 && edits[0].seg == segs[0] {
+			edits[0].repl = append(append([]byte(nil), edits[0].repl...), xmp...)
+			return edits
+		}
+	}
+	// the other edits are at or after at
+	edits = append(edits, edit{})
+	copy(edits[1:], edits)
+	edits[0] = edit{seg: segment{offset: at}, repl: xmp}
+	return edits
+}
+
+// limitGrowth drop optional replacements, last first, until the edits grow
+// the input by at most MaxGrowth bytes, or return ErrGrowthExceeded.
+func (p *Processor) limitGrowth(edits []edit) (err error) {
+	growth := 0
+	for _, e := range edits {
+		growth += len(e.repl) - e.seg.size
+	}
+	for i := len(edits) - 1; i >= 0 && growth > p.opts.MaxGrowth; i-- {
+		if e := &edits[i]; e.optional && e.repl != nil {
+			growth -= len(e.repl)
+			e.repl = nil
+		}
+	}
+	if growth > p.opts.MaxGrowth {
+		err = ErrGrowthExceeded
+	}
+	return
+}
+
+// handles report whether a handler match the segments with marker.
+func (p *Processor) handles(marker uint16) bool {
+	for _, h := range p.opts.Handlers {
+		if marker == 0xff00|uint16(h.Marker) {
+			return true
+		}
+	}
+	return false
+}
+
+// handle return the edit of the first handler matching s, ok is false if s is kept.
+func (p *Processor) handle(in []byte, s segment) (e edit, ok bool, err error) {
+	b := s.payload(in)
+	for _, h := range p.opts.Handlers {
+		if s.marker != 0xff00|uint16(h.Marker) || !bytes.HasPrefix(b, h.Prefix) {
+			continue
+		}
+		var (
+			action SegmentAction
+			out    []byte
+		)
+		callback(func() { action, out = h.Handle(b) })
+		switch action {
+		case DropSegment:
+			e, ok = edit{seg: s}, true
+		case TransformSegment:
+			if len(out) > 0xffff-2 {
+				err = ErrInvalidBlockSize
+				return
+			}
+			repl := append([]byte{0xff, h.Marker, byte((len(out) + 2) >> 8), byte(len(out) + 2)}, out...)
+			e, ok = edit{seg: s, repl: repl}, true
+		}
+		return
+	}
+	return
+}
+
+// isExif report whether s is an exif APP1 segment, including empty ones.
+func isExif(in []byte, s segment) bool {
+	if s.marker != markerAPP1 {
+		return false
+	}
+	b := s.payload(in)
+	return bytes.HasPrefix(b, exifPrefix) || emptyExif(b)
+}
+
+// keeps report whether o keep tags of the exif in a minimal exif.
+func (o *Options) keeps() bool {
+	return o.KeepOrientation || len(o.KeepTags) > 0 || o.FuzzGPS > 0
+}
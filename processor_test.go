@@ -0,0 +1,304 @@
+package exif
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"image"
+	"io"
+	"sync"
+	"testing"
+
+	"github.com/lkzz/exif/exiftag"
+)
+
+func TestProcessor(t *testing.T) {
+	var (
+		all    = NewProcessor(Options{})
+		orient = NewProcessor(Options{KeepOrientation: true, StripC2PA: true})
+	)
+	for _, name := range []string{"exif_bigEndian.jpg", "exif_littleEndian.jpg"} {
+		src := readFixture(t, name)
+		want, err := StripAll(src)
+		if err != nil {
+			t.Fatalf("StripAll(%s) error(%v)", name, err)
+		}
+		var wg sync.WaitGroup
+		for i := 0; i < 4; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if out, err := all.Process(src); err != nil || !bytes.Equal(out, want) {
+					t.Errorf("Process(%s) error(%v), output differ from StripAll", name, err)
+				}
+			}()
+		}
+		wg.Wait()
+		signed, err := InsertSegment(src, 0xeb, testJUMBF(1, "c2pa", "c2pa"), AfterAPPn)
+		if err != nil {
+			t.Fatalf("InsertSegment error(%v)", err)
+		}
+		out, err := orient.Process(signed)
+		if err != nil {
+			t.Fatalf("Process(%s) error(%v)", name, err)
+		}
+		if ok, _ := HasC2PA(out); ok {
+			t.Fatalf("Process(%s) kept the C2PA manifest", name)
+		}
+		b, err := exifBlock(out)
+		if err != nil {
+			t.Fatalf("exifBlock(%s) error(%v)", name, err)
+		}
+		tf, err := parseTIFF(b)
+		if err != nil {
+			t.Fatalf("parseTIFF(%s) error(%v)", name, err)
+		}
+		if v, _ := tf.uint(tf.ifd0, orientationTag); len(tf.ifd0) != 1 || v != 6 {
+			t.Fatalf("Process(%s) ifd0 = %v, want orientation 6 only", name, tf.ifd0)
+		}
+		var w bytes.Buffer
+		if n, err := orient.Copy(&w, signed); err != nil || n != int64(len(out)) || !bytes.Equal(w.Bytes(), out) {
+			t.Fatalf("Copy(%s) = %d, %v, output differ from Process", name, n, err)
+		}
+		if _, err = all.Process(want); err != ErrNoExif {
+			t.Fatalf("Process(stripped %s) error(%v), want %v", name, err, ErrNoExif)
+		}
+	}
+}
+
+func TestProcessorMaxGrowth(t *testing.T) {
+	src, err := Strip(readFixture(t, "exif_bigEndian.jpg"))
+	if err != nil {
+		t.Fatalf("Strip error(%v)", err)
+	}
+	// the stripped orientation grows when rewritten with its next IFD pointer
+	if out, _ := NewProcessor(Options{KeepOrientation: true}).Process(src); len(out) <= len(src) {
+		t.Fatalf("Process output %d bytes, want more than %d", len(out), len(src))
+	}
+	p := NewProcessor(Options{KeepOrientation: true, LimitGrowth: true})
+	out, err := p.Process(src)
+	if err != nil {
+		t.Fatalf("Process error(%v)", err)
+	}
+	if len(out) > len(src) {
+		t.Fatalf("Process output %d bytes, want at most %d", len(out), len(src))
+	}
+	var buf bytes.Buffer
+	if _, _, err = p.NewStream(&buf).Feed(src); err != nil {
+		t.Fatalf("Feed error(%v)", err)
+	}
+	if !bytes.Equal(buf.Bytes(), out) {
+		t.Fatalf("Stream output differ from Process")
+	}
+	p = NewProcessor(Options{KeepOrientation: true, LimitGrowth: true, MaxGrowth: 4})
+	if out, _ = p.Process(src); len(out) != len(src)+4 {
+		t.Fatalf("Process output %d bytes, want %d", len(out), len(src)+4)
+	}
+	// a handler growth can not be dropped
+	if src, err = InsertSegment(src, 0xe9, []byte("MYCO\x00"), AfterAPPn); err != nil {
+		t.Fatalf("InsertSegment error(%v)", err)
+	}
+	p = NewProcessor(Options{LimitGrowth: true, Handlers: []SegmentHandler{
+		{Marker: 0xe9, Prefix: []byte("MYCO\x00"), Handle: func(b []byte) (SegmentAction, []byte) {
+			return TransformSegment, append(b, make([]byte, 1024)...)
+		}},
+	}})
+	if _, err = p.Process(src); err != ErrGrowthExceeded {
+		t.Fatalf("Process error(%v), want ErrGrowthExceeded", err)
+	}
+	if _, _, err = p.NewStream(io.Discard).Feed(src); err != ErrGrowthExceeded {
+		t.Fatalf("Feed error(%v), want ErrGrowthExceeded", err)
+	}
+}
+
+func TestProcessorHandlers(t *testing.T) {
+	src := readFixture(t, "exif_bigEndian.jpg")
+	for _, seg := range []struct {
+		marker  byte
+		payload string
+	}{{0xe9, "MYCO\x00secret"}, {0xe9, "OTHR\x00kept"}, {0xea, "MYCO\x00ticket-42"}} {
+		var err error
+		if src, err = InsertSegment(src, seg.marker, []byte(seg.payload), AfterAPPn); err != nil {
+			t.Fatalf("InsertSegment error(%v)", err)
+		}
+	}
+	p := NewProcessor(Options{Handlers: []SegmentHandler{
+		{Marker: 0xe9, Prefix: []byte("MYCO\x00"), Handle: func([]byte) (SegmentAction, []byte) { return DropSegment, nil }},
+		{Marker: 0xea, Prefix: []byte("MYCO\x00"), Handle: func(b []byte) (SegmentAction, []byte) {
+			return TransformSegment, bytes.ToUpper(b)
+		}},
+	}})
+	out, err := p.Process(src)
+	if err != nil {
+		t.Fatalf("Process error(%v)", err)
+	}
+	for s, want := range map[string]bool{"secret": false, "OTHR\x00kept": true, "MYCO\x00TICKET-42": true, "ticket": false} {
+		if bytes.Contains(out, []byte(s)) != want {
+			t.Fatalf("output contain %q = %v, want %v", s, !want, want)
+		}
+	}
+	var buf bytes.Buffer
+	st := p.NewStream(&buf)
+	for i := 0; i < len(src); i += 7 {
+		if _, _, err = st.Feed(src[i:min(i+7, len(src))]); err != nil {
+			t.Fatalf("Feed error(%v)", err)
+		}
+	}
+	if !bytes.Equal(buf.Bytes(), out) {
+		t.Fatalf("Stream output differ from Process")
+	}
+}
+
+func TestProcessorRelocateXMP(t *testing.T) {
+	src := readFixture(t, "exif_bigEndian.jpg")
+	xmp := append(append([]byte(nil), xmpPrefix...), "<x:xmpmeta/>"...)
+	src, err := InsertSegment(src, 0xe1, xmp, BeforeSOS)
+	if err != nil {
+		t.Fatalf("InsertSegment error(%v)", err)
+	}
+	out, err := NewProcessor(Options{RelocateXMP: true}).Process(src)
+	if err != nil {
+		t.Fatalf("Process error(%v)", err)
+	}
+	want, _ := NewProcessor(Options{}).Process(src)
+	if len(out) != len(want) {
+		t.Fatalf("Process output %d bytes, want %d", len(out), len(want))
+	}
+	segs, _, err := readSegments(out)
+	if err != nil {
+		t.Fatalf("readSegments error(%v)", err)
+	}
+	i := 0
+	if segs[0].marker == 0xff00|markerAPP0 {
+		i = 1
+	}
+	if !isXMP(out, segs[i]) || !bytes.Equal(segs[i].payload(out), xmp) {
+		t.Fatalf("segment #%d is not the xmp packet", i)
+	}
+	for _, s := range segs[i+1:] {
+		if isXMP(out, s) {
+			t.Fatalf("xmp packet left at %d", s.offset)
+		}
+	}
+}
+
+func TestProcessorLenient(t *testing.T) {
+	strict, lenient := NewProcessor(Options{}), NewProcessor(Options{Lenient: true})
+	truncated := readFixture(t, "truncated_exif.jpg")
+	if _, err := strict.Process(truncated); err == nil {
+		t.Fatalf("Process(truncated_exif.jpg) succeeded in strict mode")
+	}
+	out, ws, err := lenient.ProcessWarnings(truncated)
+	if err != nil || len(ws) == 0 || len(out) >= len(truncated) {
+		t.Fatalf("ProcessWarnings(truncated_exif.jpg) = %d bytes, %v, %v", len(out), ws, err)
+	}
+	src := readFixture(t, "exif_bigEndian.jpg")
+	segs, _, _ := readSegments(src)
+	at := segs[0].offset + segs[0].size
+	stray := append(append(append([]byte(nil), src[:at]...), "junk"...), src[at:]...)
+	want, _ := strict.Process(src)
+	out, ws, err = lenient.ProcessWarnings(stray)
+	if err != nil || len(ws) != 1 || ws[0].Offset != at || len(out) != len(want)+4 {
+		t.Fatalf("ProcessWarnings(stray bytes) = %d bytes, %v, %v", len(out), ws, err)
+	}
+	none, _ := StripAll(src)
+	if out, ws, err = lenient.ProcessWarnings(none); err != nil || !bytes.Equal(out, none) || len(ws) != 1 {
+		t.Fatalf("ProcessWarnings(no exif) = %v, %v, want unchanged with a warning", ws, err)
+	}
+}
+
+func TestProcessorCopyHash(t *testing.T) {
+	src := readFixture(t, "exif_littleEndian.jpg")
+	p := NewProcessor(Options{KeepOrientation: true})
+	want, _ := p.Process(src)
+	sum := sha256.Sum256(want)
+	var w bytes.Buffer
+	h := sha256.New()
+	if n, err := p.CopyHash(&w, src, h); err != nil || n != int64(len(want)) || !bytes.Equal(h.Sum(nil), sum[:]) {
+		t.Fatalf("CopyHash = %d, %v, digest %x, want %x", n, err, h.Sum(nil), sum)
+	}
+	h.Reset()
+	if _, err := p.CopyHash(nil, src, h); err != nil || !bytes.Equal(h.Sum(nil), sum[:]) {
+		t.Fatalf("CopyHash(nil) error(%v), digest differ", err)
+	}
+	h.Reset()
+	w.Reset()
+	s := p.NewStream(&w)
+	s.SetHash(h)
+	if _, _, err := s.Feed(src); err != nil || !bytes.Equal(h.Sum(nil), sum[:]) {
+		t.Fatalf("Stream error(%v), digest differ", err)
+	}
+}
+
+func TestProcessorKeepTags(t *testing.T) {
+	payload, err := Build(image.Config{Width: 16, Height: 12}, Params{Orientation: 6, GPS: &GPS{Latitude: 38.88975, Longitude: -77.0089}})
+	if err != nil {
+		t.Fatalf("Build error(%v)", err)
+	}
+	src, _ := SetRawExif(readFixture(t, "jfif_bigEndian.jpg"), payload)
+	if src, err = Edit(src).Set(exiftag.Model, "DSC-1").Commit(); err != nil {
+		t.Fatalf("Commit error(%v)", err)
+	}
+	p := NewProcessor(Options{KeepTags: []exiftag.ID{exiftag.Model}, FuzzGPS: 1})
+	var buf bytes.Buffer
+	if _, err = p.Copy(&buf, src); err != nil {
+		t.Fatalf("Copy error(%v)", err)
+	}
+	e := mustDecode(t, buf.Bytes())
+	if m, err := Get[string](e, exiftag.Model); err != nil || m != "DSC-1" {
+		t.Fatalf("Model = %q, error(%v), want DSC-1", m, err)
+	}
+	if g, err := e.Location(); err != nil || g.Latitude != 39 || g.Longitude != -77 {
+		t.Fatalf("Location = %+v, error(%v), want 39, -77", g, err)
+	}
+	if _, err = Get[uint16](e, exiftag.Orientation); err == nil {
+		t.Fatal("Orientation kept without KeepOrientation")
+	}
+	var streamed bytes.Buffer
+	if _, err = p.NewStream(&streamed).ReadFrom(bytes.NewReader(src)); err != nil || !bytes.Equal(streamed.Bytes(), buf.Bytes()) {
+		t.Fatalf("Stream error(%v), output differ from Copy", err)
+	}
+}
+
+func TestProcessorRelocateXMPDropAPP0(t *testing.T) {
+	src := readFixture(t, "jfif_bigEndian.jpg")
+	xmp := append(append([]byte(nil), xmpPrefix...), "<x:xmpmeta/>"...)
+	src, err := InsertSegment(src, 0xe1, xmp, BeforeSOS)
+	if err != nil {
+		t.Fatalf("InsertSegment error(%v)", err)
+	}
+	if segs, _, _ := readSegments(src); segs[0].marker != 0xff00|markerAPP0 {
+		t.Fatal("fixture has no leading APP0")
+	}
+	for _, action := range []SegmentAction{DropSegment, TransformSegment} {
+		p := NewProcessor(Options{RelocateXMP: true, Handlers: []SegmentHandler{{Marker: 0xe0, Handle: func([]byte) (SegmentAction, []byte) {
+			return action, []byte("JFIF\x00")
+		}}}})
+		out, err := p.Process(src)
+		if err != nil {
+			t.Fatalf("Process(%d) error(%v)", action, err)
+		}
+		segs, _, err := readSegments(out)
+		if err != nil {
+			t.Fatalf("readSegments error(%v)", err)
+		}
+		i := 0
+		if action == TransformSegment {
+			if !bytes.Equal(segs[0].payload(out), []byte("JFIF\x00")) {
+				t.Fatal("APP0 not transformed")
+			}
+			i = 1
+		}
+		found := false
+		for ; i < len(segs) && isXMP(out, segs[i]); i++ {
+			found = found || bytes.Equal(segs[i].payload(out), xmp)
+		}
+		if !found {
+			t.Fatalf("Process(%d) xmp packet not relocated", action)
+		}
+		for _, s := range segs[i:] {
+			if isXMP(out, s) {
+				t.Fatalf("Process(%d) xmp packet left at %d", action, s.offset)
+			}
+		}
+	}
+}
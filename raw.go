@@ -0,0 +1,47 @@
+package exif
+
+import "bytes"
+
+// RawExif return a copy of the payload of the first exif APP1 segment, which
+// is the exif header followed by the raw tiff block.
+func RawExif(in []byte) (blob []byte, err error) {
+	defer recoverCorrupt(&err)
+	var segs []segment
+	if segs, _, err = readSegments(in); err != nil {
+		return
+	}
+	for _, s := range segs {
+		if p := s.payload(in); s.marker == markerAPP1 && bytes.HasPrefix(p, exifPrefix) {
+			blob = append([]byte(nil), p...)
+			return
+		}
+	}
+	err = ErrNoExif
+	return
+}
+
+// SetRawExif return in with its exif replaced by blob verbatim, blob is an
+// APP1 payload as returned by RawExif or a raw tiff block, in which case the
+// exif header is added. The exif APP1 is replaced in place, or inserted after
+// JFIF APP0 if in has none.
+func SetRawExif(in, blob []byte) (out []byte, err error) {
+	defer recoverCorrupt(&err)
+	if !bytes.HasPrefix(blob, exifPrefix) {
+		blob = append(append(make([]byte, 0, len(exifPrefix)+len(blob)), exifPrefix...), blob...)
+	}
+	if len(blob) > 0xffff-2 {
+		err = ErrInvalidBlockSize
+		return
+	}
+	var segs []segment
+	if segs, _, err = readSegments(in); err != nil {
+		return
+	}
+	for _, s := range segs {
+		if s.marker == markerAPP1 && bytes.HasPrefix(s.payload(in), exifPrefix) {
+			seg := append([]byte{0xff, 0xe1, byte((len(blob) + 2) >> 8), byte(len(blob) + 2)}, blob...)
+			return splice(in, s.offset, s.size, seg)
+		}
+	}
+	return InsertSegment(in, 0xe1, blob, AfterAPP0)
+}
@@ -0,0 +1,46 @@
+package exif
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestRawExif(t *testing.T) {
+	src := readFixture(t, "exif_littleEndian.jpg")
+	blob, err := RawExif(src)
+	if err != nil {
+		t.Fatalf("RawExif error(%v)", err)
+	}
+	if b, _ := exifBlock(src); !bytes.Equal(blob[len(exifPrefix):], b) {
+		t.Fatalf("RawExif did not return the exact APP1 payload")
+	}
+	bare, err := StripAll(src)
+	if err != nil {
+		t.Fatalf("StripAll error(%v)", err)
+	}
+	if _, err = RawExif(bare); err != ErrNoExif {
+		t.Fatalf("RawExif(stripped) error(%v), want %v", err, ErrNoExif)
+	}
+	// reinjecting the blob, with or without exif header, restore the original
+	for _, b := range [][]byte{blob, blob[len(exifPrefix):]} {
+		out, err := SetRawExif(bare, b)
+		if err != nil {
+			t.Fatalf("SetRawExif error(%v)", err)
+		}
+		if !bytes.Equal(out, src) {
+			t.Fatalf("SetRawExif(RawExif(in)) differ from in")
+		}
+	}
+	other := readFixture(t, filename)
+	otherBlob, _ := RawExif(other)
+	out, err := SetRawExif(src, otherBlob)
+	if err != nil {
+		t.Fatalf("SetRawExif error(%v)", err)
+	}
+	if got, _ := RawExif(out); !bytes.Equal(got, otherBlob) {
+		t.Fatalf("SetRawExif did not replace the exif")
+	}
+	if _, err = SetRawExif(src, make([]byte, 0x10000)); err != ErrInvalidBlockSize {
+		t.Fatalf("SetRawExif(64KB) error(%v), want %v", err, ErrInvalidBlockSize)
+	}
+}
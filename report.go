@@ -0,0 +1,95 @@
+package exif
+
+// Range is the byte range [Offset, Offset+Size).
+type Range struct {
+	Offset int
+	Size   int
+}
+
+// Retained is a segment kept by a strip, found at In in the input and at Out
+// in the output. SOI is reported as a 2 bytes segment at offset 0, and the
+// image data from the first SOS to the end of the input as one segment with
+// the SOS marker.
+type Retained struct {
+	Marker uint16
+	In     int
+	Out    int
+	Size   int
+}
+
+// Report describe exactly which bytes a strip changed, so that digests or
+// signatures over the image can be updated.
+type Report struct {
+	Removed  []Range    // ranges of the input which were removed
+	Inserted []Range    // ranges of the output which were not in the input
+	Retained []Retained // every retained segment, copied verbatim
+}
+
+// StripReport is like Strip and also return the report of the changed bytes.
+func StripReport(in []byte) (out []byte, r *Report, err error) {
+	defer recoverCorrupt(&err)
+	if out, err = Strip(in); err != nil {
+		return
+	}
+	r, err = stripReport(in, out)
+	return
+}
+
+// StripAllReport is like StripAll and also return the report of the changed bytes.
+func StripAllReport(in []byte) (out []byte, r *Report, err error) {
+	defer recoverCorrupt(&err)
+	if out, err = StripAll(in); err != nil {
+		return
+	}
+	r, err = stripReport(in, out)
+	return
+}
+
+// stripReport report the changes made to in by Strip or StripAll, which
+// replace the first APP1 segment by the (possibly empty) rest of out.
+func stripReport(in, out []byte) (r *Report, err error) {
+	var (
+		segs []segment
+		end  int
+	)
+	if segs, end, err = readSegments(in); err != nil {
+		return
+	}
+	for _, s := range segs {
+		if s.marker == markerAPP1 {
+			r = newReport(segs, end, len(in), s.offset, s.size, len(out)-len(in)+s.size)
+			return
+		}
+	}
+	err = ErrNoExif
+	return
+}
+
+// newReport report replacing the removed bytes at offset at of the input by
+// inserted new bytes, segs and end are the segments of the input as returned
+// by readSegments and total is the input size.
+func newReport(segs []segment, end, total, at, removed, inserted int) (r *Report) {
+	r = new(Report)
+	if removed > 0 {
+		r.Removed = append(r.Removed, Range{Offset: at, Size: removed})
+	}
+	if inserted > 0 {
+		r.Inserted = append(r.Inserted, Range{Offset: at, Size: inserted})
+	}
+	r.Retained = append(r.Retained, Retained{Marker: markerSOI, Size: 2})
+	moved := func(offset int) int {
+		if offset < at {
+			return offset
+		}
+		return offset - removed + inserted
+	}
+	for _, s := range segs {
+		if s.offset+s.size <= at || s.offset >= at+removed {
+			r.Retained = append(r.Retained, Retained{Marker: s.marker, In: s.offset, Out: moved(s.offset), Size: s.size})
+		}
+	}
+	if end < total {
+		r.Retained = append(r.Retained, Retained{Marker: markerSOS, In: end, Out: moved(end), Size: total - end})
+	}
+	return
+}
@@ -0,0 +1,37 @@
+package exif
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestStripReport(t *testing.T) {
+	for _, name := range []string{"exif_bigEndian.jpg", "exif_littleEndian.jpg", "jfif_bigEndian.jpg"} {
+		src := readFixture(t, name)
+		for fn, strip := range map[string]func([]byte) ([]byte, *Report, error){"StripReport": StripReport, "StripAllReport": StripAllReport} {
+			out, r, err := strip(src)
+			if err != nil {
+				t.Fatalf("%s(%s) error(%v)", fn, name, err)
+			}
+			if s := r.Retained[0]; s.Marker != markerSOI || s.In != 0 || s.Out != 0 || s.Size != 2 {
+				t.Fatalf("%s(%s) first retained segment %+v, want SOI", fn, name, s)
+			}
+			var in, kept int
+			for _, s := range r.Retained {
+				if !bytes.Equal(src[s.In:s.In+s.Size], out[s.Out:s.Out+s.Size]) {
+					t.Fatalf("%s(%s) segment %x at %d not retained at %d", fn, name, s.Marker, s.In, s.Out)
+				}
+				in, kept = in+s.Size, kept+s.Size
+			}
+			for _, rg := range r.Removed {
+				in += rg.Size
+			}
+			for _, rg := range r.Inserted {
+				kept += rg.Size
+			}
+			if in != len(src) || kept != len(out) {
+				t.Fatalf("%s(%s) report cover %d/%d input and %d/%d output bytes", fn, name, in, len(src), kept, len(out))
+			}
+		}
+	}
+}
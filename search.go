@@ -0,0 +1,105 @@
+package exif
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// userCommentTag is the UNDEFINED text tag of the exif IFD.
+const userCommentTag = 0x9286
+
+// xpTags are the names of the Windows XP text tags of IFD0, whose BYTE values
+// are UCS-2 little endian.
+var xpTags = map[uint16]string{
+	0x9c9b:        "XPTitle",
+	0x9c9c:        "XPComment",
+	0x9c9d:        "XPAuthor",
+	xpKeywordsTag: "XPKeywords",
+	0x9c9f:        "XPSubject",
+}
+
+// TagMatch is a metadata text value holding the searched text.
+type TagMatch struct {
+	Source string // "exif", "iptc" or "xmp"
+	Tag    TagID  // exif tag, zero for the other sources
+	// Name is the exif tag name like "Artist", or its id like "0xc4a5" if
+	// unknown, the IPTC dataset like "2:80", or the xmp property like "dc:creator".
+	Name  string
+	Value string
+}
+
+// ContainsText return the text values of the exif, IPTC and xmp metadata of
+// in containing substr, ignoring case, for scanners looking for personal
+// data. The metadata which are absent or can not be parsed are skipped.
+func ContainsText(in []byte, substr string) (matches []TagMatch, err error) {
+	defer recoverCorrupt(&err)
+	var segs []segment
+	if segs, _, err = readSegments(in); err != nil {
+		return
+	}
+	needle := strings.ToLower(substr)
+	match := func(m TagMatch) {
+		if strings.Contains(strings.ToLower(m.Value), needle) {
+			matches = append(matches, m)
+		}
+	}
+	for _, s := range segs {
+		p := s.payload(in)
+		switch {
+		case s.marker == markerAPP1 && bytes.HasPrefix(p, exifPrefix):
+			if t, terr := parseTIFF(p[len(exifPrefix):]); terr == nil {
+				t.texts(match)
+			}
+		case s.marker == markerAPP13 && bytes.HasPrefix(p, photoshopPrefix):
+			iptcTexts(p[len(photoshopPrefix):], match)
+		case s.marker == markerAPP1 && bytes.HasPrefix(p, xmpPrefix):
+			props, perr := parseXMP(p[len(xmpPrefix):])
+			if perr != nil {
+				continue
+			}
+			names := make([]string, 0, len(props))
+			for name := range props {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			for _, name := range names {
+				for _, v := range props[name] {
+					match(TagMatch{Source: "xmp", Name: name, Value: v})
+				}
+			}
+		}
+	}
+	return
+}
+
+// texts call fn with the ASCII tags, the decodable UNDEFINED text tags and the
+// Windows XP tags of t.
+func (t *tiff) texts(fn func(TagMatch)) {
+	specs := map[string]map[uint16]tagSpec{"ifd0": tiffSpecs, "exif": exifSpecs, "gps": gpsSpecs, "interop": interopSpecs, "ifd1": tiffSpecs}
+	for _, ifd := range t.ifds() {
+		for _, e := range ifd.es {
+			var v string
+			name := specs[ifd.name][e.tag].name
+			switch {
+			case e.typ == typeASCII:
+				v = trimNUL(e.value)
+			case ifd.name == "ifd0" && xpTags[e.tag] != "" && e.typ == typeByte:
+				v, name = xpText(e.value), xpTags[e.tag]
+			case ifd.name == "exif" && e.tag == userCommentTag,
+				ifd.name == "gps" && (e.tag == gpsProcessingMethodTag || e.tag == gpsAreaInformationTag):
+				var err error
+				if v, err = t.text(e); err != nil {
+					continue
+				}
+			default:
+				continue
+			}
+			if name == "" {
+				name = fmt.Sprintf("%#04x", e.tag)
+			}
+			fn(TagMatch{Source: "exif", Tag: TagID{IFD: ifd.name, Tag: e.tag}, Name: name, Value: v})
+		}
+	}
+}
@@ -0,0 +1,85 @@
+package exif
+
+import (
+	"encoding/binary"
+	"reflect"
+	"testing"
+	"unicode/utf16"
+
+	"github.com/lkzz/exif/exiftag"
+)
+
+// testIPTC return an APP13 payload holding the IIM datasets of record 2.
+func testIPTC(datasets map[byte]string) []byte {
+	var iim []byte
+	for _, ds := range []byte{80, 120} {
+		if v, ok := datasets[ds]; ok {
+			iim = append(iim, 0x1c, 2, ds, byte(len(v)>>8), byte(len(v)))
+			iim = append(iim, v...)
+		}
+	}
+	b := append([]byte(nil), photoshopPrefix...)
+	b = append(b, "8BIM\x04\x04\x00\x00"...) // empty pascal name, padded
+	b = append(b, byte(len(iim)>>24), byte(len(iim)>>16), byte(len(iim)>>8), byte(len(iim)))
+	b = append(b, iim...)
+	if len(iim)%2 == 1 {
+		b = append(b, 0)
+	}
+	return b
+}
+
+func TestContainsText(t *testing.T) {
+	in, err := StripAll(readFixture(t, "jfif_bigEndian.jpg"))
+	if err != nil {
+		t.Fatalf("StripAll error(%v)", err)
+	}
+	if in, err = Edit(in).
+		Set(exiftag.Artist, "Jane Doe").
+		Set(exiftag.UserComment, append(append([]byte(nil), charsetASCII...), "mail JANE@example.com"...)).
+		Set(exiftag.Software, "render").
+		Commit(); err != nil {
+		t.Fatalf("Commit error(%v)", err)
+	}
+	e := mustDecode(t, in)
+	for tag, v := range map[uint16]string{0x9c9d: "Jane Roe", 0x9c9b: "holiday"} {
+		var b []byte
+		for _, u := range utf16.Encode([]rune(v + "\x00")) {
+			b = binary.LittleEndian.AppendUint16(b, u)
+		}
+		e.t.ifd0 = append(e.t.ifd0, e.t.bytes(tag, b...))
+	}
+	payload, _, err := e.t.app1()
+	if err != nil {
+		t.Fatalf("app1 error(%v)", err)
+	}
+	if in, err = SetRawExif(in, payload); err != nil {
+		t.Fatalf("SetRawExif error(%v)", err)
+	}
+	if in, err = InsertSegment(in, 0xed, testIPTC(map[byte]string{80: "jane doe", 120: "a caption"}), AfterAPPn); err != nil {
+		t.Fatalf("InsertSegment error(%v)", err)
+	}
+	packet := `<x:xmpmeta xmlns:x="adobe:ns:meta/"><rdf:RDF xmlns:rdf="http://www.w3.org/1999/02/22-rdf-syntax-ns#">
+<rdf:Description rdf:about="" xmlns:dc="http://purl.org/dc/elements/1.1/">
+<dc:creator><rdf:Seq><rdf:li>Jane</rdf:li></rdf:Seq></dc:creator>
+</rdf:Description></rdf:RDF></x:xmpmeta>`
+	if in, err = InsertSegment(in, 0xe1, append(append([]byte(nil), xmpPrefix...), packet...), AfterAPPn); err != nil {
+		t.Fatalf("InsertSegment error(%v)", err)
+	}
+	matches, err := ContainsText(in, "jane")
+	if err != nil {
+		t.Fatalf("ContainsText error(%v)", err)
+	}
+	want := []TagMatch{
+		{Source: "exif", Tag: TagID{IFD: "ifd0", Tag: 0x013b}, Name: "Artist", Value: "Jane Doe"},
+		{Source: "exif", Tag: TagID{IFD: "ifd0", Tag: 0x9c9d}, Name: "XPAuthor", Value: "Jane Roe"},
+		{Source: "exif", Tag: TagID{IFD: "exif", Tag: userCommentTag}, Name: "UserComment", Value: "mail JANE@example.com"},
+		{Source: "iptc", Name: "2:80", Value: "jane doe"},
+		{Source: "xmp", Name: "dc:creator", Value: "Jane"},
+	}
+	if !reflect.DeepEqual(matches, want) {
+		t.Fatalf("ContainsText = %+v, want %+v", matches, want)
+	}
+	if matches, err = ContainsText(in, "nobody"); err != nil || matches != nil {
+		t.Fatalf("ContainsText(nobody) = %v, %v", matches, err)
+	}
+}
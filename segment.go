@@ -0,0 +1,213 @@
+package exif
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// jpeg marker bytes
+const (
+	markerAPP0 = 0xe0
+	markerAPPn = 0xef
+	markerCOM  = 0xfe
+)
+
+// jfifPrefix is the identifier at the start of a JFIF APP0 payload.
+var jfifPrefix = []byte("JFIF\x00")
+
+// segment errors
+var (
+	ErrInvalidMarker   = errors.New("invalid segment marker")
+	ErrInvalidPosition = errors.New("invalid segment position")
+)
+
+// Position is where InsertSegment put a segment in the marker sequence.
+type Position int
+
+// segment positions
+const (
+	AfterSOI  Position = iota // directly after SOI
+	AfterAPP0                 // after a leading JFIF APP0, or after SOI if there is none
+	AfterAPPn                 // after the last APPn segment before image data
+	BeforeSOS                 // directly before the first SOS
+)
+
+// segment is a marker segment of a jpeg image.
+type segment struct {
+	marker uint16
+	offset int // offset of the marker
+	size   int // size of the segment, including the marker
+}
+
+// SegmentInfo describe a marker segment before the image data.
+type SegmentInfo struct {
+	Marker uint16
+	Offset int // offset of the marker
+	Size   int // size of the segment, including the marker
+	// Kind is the metadata kind, like "exif", "xmp", "c2pa" or "APP13" as
+	// counted by Stats, empty for the segments which are not metadata.
+	Kind string
+	// JUMBF describe the box of the APP11 segments holding JUMBF boxes,
+	// like C2PA manifests, JPEG XT or JPEG Universal Metadata, nil otherwise.
+	JUMBF *JUMBF
+}
+
+// Segments return the marker segments of in before the image data, so
+// callers can decide which payloads to keep.
+func Segments(in []byte) (infos []SegmentInfo, err error) {
+	defer recoverCorrupt(&err)
+	var segs []segment
+	if segs, _, err = readSegments(in); err != nil {
+		return
+	}
+	boxes := c2paBoxes(in, segs)
+	infos = make([]SegmentInfo, 0, len(segs))
+	for _, s := range segs {
+		info := SegmentInfo{Marker: s.marker, Offset: s.offset, Size: s.size, Kind: segmentKind(in, s, boxes)}
+		if s.marker == markerAPP11 {
+			if j, ok := jumbfInfo(s.payload(in), boxes); ok {
+				info.JUMBF = &j
+			}
+		}
+		infos = append(infos, info)
+	}
+	return
+}
+
+// payload return the segment data following the size field.
+func (s segment) payload(in []byte) []byte {
+	return in[s.offset+4 : s.offset+s.size]
+}
+
+// readSegments return the segments between SOI and the first SOS, end is the
+// offset where reading stopped, which is the SOS offset for well formed images.
+func readSegments(in []byte) (segs []segment, end int, err error) {
+	return appendSegments(nil, in)
+}
+
+// appendSegments is readSegments appending the segments to dst.
+func appendSegments(dst []segment, in []byte) (segs []segment, end int, err error) {
+	return scanSegments(dst, in, nil)
+}
+
+// scanSegments is appendSegments, working around corrupt segments if warn
+// is not nil: stray bytes before a marker are skipped and a truncated last
+// segment end at the end of in.
+func scanSegments(dst []segment, in []byte, warn func(Warning)) (segs []segment, end int, err error) {
+	segs = dst
+	if len(in) < 2 || binary.BigEndian.Uint16(in) != markerSOI {
+		err = ErrMissSOIMarker
+		return
+	}
+	end = 2
+	for end+4 <= len(in) {
+		marker := binary.BigEndian.Uint16(in[end:])
+		if marker == 0xffff { // fill byte
+			end++
+			continue
+		}
+		if marker>>8 != 0xff && warn != nil {
+			if i := bytes.IndexByte(in[end:], 0xff); i > 0 && end+i+4 <= len(in) && isSegmentMarker(in[end+i+1]) {
+				warn(Warning{Offset: end, Msg: fmt.Sprintf("%d stray bytes before marker skipped", i)})
+				end += i
+				continue
+			}
+		}
+		if marker>>8 != 0xff || marker == markerSOS || marker == markerEOI {
+			break
+		}
+		size := int(binary.BigEndian.Uint16(in[end+2:]))
+		if size >= 2 && end+2+size > len(in) && warn != nil {
+			warn(Warning{Offset: end, Msg: fmt.Sprintf("segment %#04x truncated, %d bytes missing", marker, end+2+size-len(in))})
+			segs = append(segs, segment{marker: marker, offset: end, size: len(in) - end})
+			end = len(in)
+			return
+		}
+		if size < 2 && warn != nil {
+			warn(Warning{Offset: end, Msg: fmt.Sprintf("segment %#04x has invalid size %d, rest kept verbatim", marker, size)})
+			return
+		}
+		if size < 2 || end+2+size > len(in) {
+			err = ErrInvalidBlockSize
+			return
+		}
+		segs = append(segs, segment{marker: marker, offset: end, size: size + 2})
+		end = end + size + 2
+	}
+	return
+}
+
+// isJFIF report whether s is a JFIF APP0 segment, other APP0 segments like
+// the AVI1 of motion jpeg are not.
+func isJFIF(in []byte, s segment) bool {
+	return s.marker == 0xff00|markerAPP0 && bytes.HasPrefix(s.payload(in), jfifPrefix)
+}
+
+// isSegmentMarker report whether m is the second byte of a marker holding a
+// segment before image data.
+func isSegmentMarker(m byte) bool {
+	return m >= 0xc0 && m <= 0xfe && (m < 0xd0 || m > 0xd9)
+}
+
+// findSegment return the payload following prefix of the first segment with
+// marker and prefix before image data, b is nil if there is no such segment.
+func findSegment(in []byte, marker uint16, prefix []byte) (b []byte, err error) {
+	var segs []segment
+	if segs, _, err = readSegments(in); err != nil {
+		return
+	}
+	for _, s := range segs {
+		if p := s.payload(in); s.marker == marker && bytes.HasPrefix(p, prefix) {
+			b = p[len(prefix):]
+			return
+		}
+	}
+	return
+}
+
+// InsertSegment insert an APPn or COM segment holding payload at position.
+func InsertSegment(in []byte, marker byte, payload []byte, position Position) (out []byte, err error) {
+	defer recoverCorrupt(&err)
+	if (marker < markerAPP0 || marker > markerAPPn) && marker != markerCOM {
+		err = ErrInvalidMarker
+		return
+	}
+	if len(payload) > 0xffff-2 {
+		err = ErrInvalidBlockSize
+		return
+	}
+	var (
+		segs []segment
+		end  int
+		at   = 2
+	)
+	if segs, end, err = readSegments(in); err != nil {
+		return
+	}
+	switch position {
+	case AfterSOI:
+	case AfterAPP0:
+		if len(segs) > 0 && isJFIF(in, segs[0]) {
+			at = segs[0].offset + segs[0].size
+		}
+	case AfterAPPn:
+		for _, s := range segs {
+			if m := s.marker & 0xff; m >= markerAPP0 && m <= markerAPPn {
+				at = s.offset + s.size
+			}
+		}
+	case BeforeSOS:
+		at = end
+	default:
+		err = ErrInvalidPosition
+		return
+	}
+	out = make([]byte, 0, len(in)+len(payload)+4)
+	out = append(out, in[:at]...)
+	out = append(out, 0xff, marker, byte((len(payload)+2)>>8), byte(len(payload)+2))
+	out = append(out, payload...)
+	out = append(out, in[at:]...)
+	return
+}
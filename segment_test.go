@@ -0,0 +1,57 @@
+package exif
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestInsertSegment(t *testing.T) {
+	src := readFixture(t, "jfif_bigEndian.jpg")
+	segs, end, err := readSegments(src)
+	if err != nil {
+		t.Fatalf("readSegments error(%v)", err)
+	}
+	var lastAPP int
+	for _, s := range segs {
+		if m := s.marker & 0xff; m >= markerAPP0 && m <= markerAPPn {
+			lastAPP = s.offset + s.size
+		}
+	}
+	payload := []byte("provenance")
+	for _, c := range []struct {
+		position Position
+		at       int
+	}{
+		{AfterSOI, 2},
+		{AfterAPP0, segs[0].offset + segs[0].size},
+		{AfterAPPn, lastAPP},
+		{BeforeSOS, end},
+	} {
+		out, err := InsertSegment(src, markerCOM, payload, c.position)
+		if err != nil {
+			t.Fatalf("InsertSegment(%d) error(%v)", c.position, err)
+		}
+		want := append([]byte{0xff, markerCOM, 0, byte(len(payload) + 2)}, payload...)
+		if !bytes.Equal(out[c.at:c.at+len(want)], want) {
+			t.Fatalf("InsertSegment(%d) segment not at offset %d", c.position, c.at)
+		}
+		if !bytes.Equal(out[:c.at], src[:c.at]) || !bytes.Equal(out[c.at+len(want):], src[c.at:]) {
+			t.Fatalf("InsertSegment(%d) changed the original data", c.position)
+		}
+	}
+	// an APP0 which is not JFIF is not skipped
+	avi := append([]byte(nil), src...)
+	copy(segs[0].payload(avi), "AVI1")
+	if out, err := InsertSegment(avi, markerCOM, payload, AfterAPP0); err != nil || out[2] != 0xff || out[3] != markerCOM {
+		t.Fatalf("InsertSegment(AVI1 APP0) error(%v), segment not after SOI", err)
+	}
+	if _, err = InsertSegment(src, 0xd8, payload, AfterSOI); err != ErrInvalidMarker {
+		t.Fatalf("InsertSegment(SOI) error(%v), want %v", err, ErrInvalidMarker)
+	}
+	if _, err = InsertSegment(src, markerCOM, make([]byte, 0x10000), AfterSOI); err != ErrInvalidBlockSize {
+		t.Fatalf("InsertSegment(64KB) error(%v), want %v", err, ErrInvalidBlockSize)
+	}
+	if _, err = InsertSegment(src, markerCOM, payload, Position(-1)); err != ErrInvalidPosition {
+		t.Fatalf("InsertSegment(-1) error(%v), want %v", err, ErrInvalidPosition)
+	}
+}
@@ -0,0 +1,144 @@
+package exif
+
+import (
+	"bytes"
+	"io/fs"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// Stats is the breakdown of metadata size across images.
+type Stats struct {
+	Images  int   // images added
+	Skipped int   // files and directories which are not readable jpeg images
+	Bytes   int64 // total size of the images added
+	// Segments is the size of the metadata segments by kind: "exif", "xmp",
+	// "icc", "c2pa", or the marker name like "APP13" and "COM" for others.
+	Segments map[string]int64
+	// Tags is the size of the exif tags, their 12 bytes entry plus the
+	// value stored outside of it. The thumbnail is counted with the
+	// JPEGInterchangeFormat tag of ifd1.
+	Tags map[TagID]int64
+}
+
+// Add add the metadata of the jpeg image in to s.
+func (s *Stats) Add(in []byte) (err error) {
+	defer recoverCorrupt(&err)
+	var segs []segment
+	if segs, _, err = readSegments(in); err != nil {
+		return
+	}
+	// the image is classified before s is updated
+	add := Stats{Segments: make(map[string]int64), Tags: make(map[TagID]int64)}
+	boxes := c2paBoxes(in, segs)
+	for _, seg := range segs {
+		kind := segmentKind(in, seg, boxes)
+		if kind == "" {
+			continue
+		}
+		add.Segments[kind] += int64(seg.size)
+		p := seg.payload(in)
+		if kind != "exif" || !bytes.HasPrefix(p, exifPrefix) { // empty exif may be shorter than the header
+			continue
+		}
+		// a corrupt exif block is still counted as a segment
+		if t, terr := parseTIFF(p[len(exifPrefix):]); terr == nil {
+			add.addTags(t)
+		}
+	}
+	if s.Segments == nil {
+		s.Segments = make(map[string]int64)
+		s.Tags = make(map[TagID]int64)
+	}
+	s.Images++
+	s.Bytes += int64(len(in))
+	for kind, n := range add.Segments {
+		s.Segments[kind] += n
+	}
+	for id, n := range add.Tags {
+		s.Tags[id] += n
+	}
+	return
+}
+
+// addTags add the tag sizes of t to s.
+func (s *Stats) addTags(t *tiff) {
+	for _, ifd := range t.ifds() {
+		for _, e := range ifd.es {
+			size := int64(12)
+			if len(e.value) > 4 {
+				size += int64(len(e.value))
+			}
+			s.Tags[TagID{IFD: ifd.name, Tag: e.tag}] += size
+		}
+	}
+	if len(t.thumbnail) > 0 {
+		s.Tags[TagID{IFD: "ifd1", Tag: thumbOffsetTag}] += int64(len(t.thumbnail))
+	}
+}
+
+// segmentKind return the metadata kind of seg, or "" if it is not metadata.
+func segmentKind(in []byte, seg segment, boxes map[uint16]bool) string {
+	p := seg.payload(in)
+	switch m := byte(seg.marker); {
+	case seg.marker == markerAPP1 && (bytes.HasPrefix(p, exifPrefix) || emptyExif(p)):
+		return "exif"
+	case seg.marker == markerAPP1 && bytes.HasPrefix(p, xmpPrefix):
+		return "xmp"
+	case seg.marker == markerAPP2 && bytes.HasPrefix(p, iccPrefix):
+		return "icc"
+	case seg.marker == markerAPP11 && inBoxes(boxes, p):
+		return "c2pa"
+	case m >= markerAPP0 && m <= markerAPPn:
+		return "APP" + strconv.Itoa(int(m-markerAPP0))
+	case m == markerCOM:
+		return "COM"
+	}
+	return ""
+}
+
+// StatsDir return the metadata size breakdown of the jpeg images found
+// walking dir, see StatsFS.
+func StatsDir(dir string) (s *Stats, err error) {
+	defer recoverCorrupt(&err)
+	return StatsFS(os.DirFS(dir))
+}
+
+// StatsFS return the metadata size breakdown of the jpeg images found
+// walking fsys, files and directories which can not be read or parsed are
+// counted as skipped.
+func StatsFS(fsys fs.FS) (s *Stats, err error) {
+	defer recoverCorrupt(&err)
+	s = new(Stats)
+	err = walkJPEG(fsys, func(in []byte, err error) {
+		if err != nil || s.Add(in) != nil {
+			s.Skipped++
+		}
+	})
+	return
+}
+
+// walkJPEG call fn with the content of the .jpg and .jpeg files of fsys, or
+// with the error reading them or a directory so the walk go on. Only an
+// error reading the root abort it.
+func walkJPEG(fsys fs.FS, fn func(in []byte, err error)) error {
+	return fs.WalkDir(fsys, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if name == "." {
+				return err
+			}
+			fn(nil, err)
+			return nil
+		}
+		if d.IsDir() {
+			return nil
+		}
+		if ext := strings.ToLower(path.Ext(name)); ext != ".jpg" && ext != ".jpeg" {
+			return nil
+		}
+		fn(fs.ReadFile(fsys, name))
+		return nil
+	})
+}
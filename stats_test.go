@@ -0,0 +1,88 @@
+package exif
+
+import (
+	"io/fs"
+	"testing"
+	"testing/fstest"
+)
+
+func TestStatsDir(t *testing.T) {
+	s, err := StatsDir("testdata")
+	if err != nil {
+		t.Fatalf("StatsDir error(%v)", err)
+	}
+	if s.Images == 0 || s.Skipped == 0 {
+		t.Fatalf("StatsDir images %d, skipped %d, want both", s.Images, s.Skipped)
+	}
+	if _, err = StatsDir("testdata/missing"); err == nil {
+		t.Fatalf("StatsDir(missing) error(nil), want the root error")
+	}
+	var single Stats
+	src := readFixture(t, filename)
+	if err = single.Add(src); err != nil {
+		t.Fatalf("Add error(%v)", err)
+	}
+	b, _ := exifBlock(src)
+	var tags int64
+	for _, n := range single.Tags {
+		tags += n
+	}
+	// the tags and thumbnail fit in the block with the header and IFD counts
+	if tags == 0 || tags > int64(len(b)) {
+		t.Fatalf("tags size %d, block size %d", tags, len(b))
+	}
+	if n := single.Segments["exif"]; n != int64(len(b)+len(exifPrefix)+4) {
+		t.Fatalf("exif segment size %d, want %d", n, len(b)+len(exifPrefix)+4)
+	}
+	if single.Tags[TagID{IFD: "ifd0", Tag: orientationTag}] != 12 {
+		t.Fatalf("orientation size %d, want 12", single.Tags[TagID{IFD: "ifd0", Tag: orientationTag}])
+	}
+}
+
+func TestStatsEmptyExif(t *testing.T) {
+	var s Stats
+	src := readFixture(t, "empty_exif.jpg")
+	if err := s.Add(src); err != nil {
+		t.Fatalf("Add(empty_exif) error(%v)", err)
+	}
+	if s.Images != 1 || s.Segments["exif"] != int64(len(src)-4) || len(s.Tags) != 0 {
+		t.Fatalf("Add(empty_exif) = %+v", s)
+	}
+	// an APP1 payload shorter than the exif header
+	short := []byte{0xff, 0xd8, 0xff, 0xe1, 0x00, 0x04, 'E', 'x', 0xff, 0xd9}
+	if err := s.Add(short); err != nil {
+		t.Fatalf("Add(short exif) error(%v)", err)
+	}
+	if s.Images != 2 || s.Segments["exif"] != int64(len(src)-4)+6 {
+		t.Fatalf("Add(short exif) = %+v", s)
+	}
+}
+
+// unreadableFS is fsys failing to open the file name.
+type unreadableFS struct {
+	fsys fs.FS
+	name string
+}
+
+func (u unreadableFS) Open(name string) (fs.File, error) {
+	if name == u.name {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrPermission}
+	}
+	return u.fsys.Open(name)
+}
+
+func TestStatsFSUnreadable(t *testing.T) {
+	src := readFixture(t, filename)
+	fsys := unreadableFS{fsys: fstest.MapFS{
+		"a.jpg":     {Data: src},
+		"bad.jpg":   {Data: src},
+		"sub/b.jpg": {Data: src},
+	}, name: "bad.jpg"}
+	s, err := StatsFS(fsys)
+	if err != nil {
+		t.Fatalf("StatsFS error(%v)", err)
+	}
+	if s.Images != 2 || s.Skipped != 1 {
+		t.Fatalf("StatsFS images %d, skipped %d, want 2, 1", s.Images, s.Skipped)
+	}
+}
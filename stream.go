@@ -0,0 +1,200 @@
+package exif
+
+import (
+	"encoding/binary"
+	"hash"
+	"io"
+)
+
+// Stream remove the exif of a jpeg image received in chunks and forward the
+// rest to a writer as it arrives, for proxies which can not buffer the whole
+// body. Only the segments which may be removed (APP1, APP11 if C2PA
+// manifests are stripped, and those of handlers) are buffered, which is at
+// most 64KB. Unlike Processor, an image without exif is forwarded unchanged.
+type Stream struct {
+	p     *Processor
+	w     io.Writer
+	buf   []byte // SOI, segment header or segment being read
+	need  int    // size of buf before the next step
+	left  int    // bytes of the current segment to forward
+	whole bool   // buf hold a whole segment
+	done  bool   // image data is reached
+	kept  bool   // the orientation is kept
+	grow  int    // output size minus input size so far
+	boxes map[uint16]bool
+}
+
+// NewStream return a Stream writing to w, configured with the options of p.
+func (p *Processor) NewStream(w io.Writer) *Stream {
+	return &Stream{p: p, w: w, need: 2}
+}
+
+// SetHash make s also write the output to h as it is produced, it must be
+// called before the first Feed.
+func (s *Stream) SetHash(h hash.Hash) {
+	s.w = io.MultiWriter(s.w, h)
+}
+
+// Feed process the next chunk of the image. It consume all bytes of p unless
+// it return an error, keeping the incomplete segment headers it needs.
+// done is true once image data is reached, from then on all bytes are
+// forwarded verbatim, so the rest of the body may be copied to the writer
+// directly.
+func (s *Stream) Feed(p []byte) (consumed int, done bool, err error) {
+	defer recoverCorrupt(&err)
+	for len(p) > 0 && err == nil {
+		var n int
+		switch {
+		case s.done:
+			n, err = s.w.Write(p)
+		case s.left > 0:
+			if n = len(p); n > s.left {
+				n = s.left
+			}
+			n, err = s.w.Write(p[:n])
+			s.left -= n
+		default:
+			if n = s.need - len(s.buf); n > len(p) {
+				n = len(p)
+			}
+			s.buf = append(s.buf, p[:n]...)
+			if len(s.buf) == s.need {
+				err = s.step()
+			}
+		}
+		consumed, p = consumed+n, p[n:]
+	}
+	done = s.done
+	return
+}
+
+// ReadFrom feed s with r until EOF, then copy the rest of r once image data
+// is reached. n is the number of bytes read, which may exceed 4GB for images
+// with appended video.
+func (s *Stream) ReadFrom(r io.Reader) (n int64, err error) {
+	defer recoverCorrupt(&err)
+	buf := make([]byte, 32<<10)
+	for !s.done {
+		m, rerr := r.Read(buf)
+		n += int64(m)
+		if _, _, err = s.Feed(buf[:m]); err != nil {
+			return
+		}
+		if rerr == io.EOF {
+			return
+		}
+		if rerr != nil {
+			err = rerr
+			return
+		}
+	}
+	m, err := io.Copy(s.w, r)
+	n += m
+	return
+}
+
+// step handle buf once it hold need bytes.
+func (s *Stream) step() (err error) {
+	switch {
+	case s.need == 2: // SOI
+		if binary.BigEndian.Uint16(s.buf) != markerSOI {
+			return ErrMissSOIMarker
+		}
+		return s.flush(2)
+	case s.whole:
+		return s.segment()
+	}
+	marker := binary.BigEndian.Uint16(s.buf)
+	switch m := marker & 0xff; {
+	case marker>>8 != 0xff || marker == markerSOS || marker == markerEOI:
+		s.done = true
+		return s.flush(len(s.buf))
+	case m == 0xff: // fill byte
+		return s.flush(1)
+	case m == 0x01 || (m >= 0xd0 && m <= 0xd7): // marker without size
+		return s.flush(2)
+	}
+	size := int(binary.BigEndian.Uint16(s.buf[2:]))
+	if size < 2 {
+		return ErrInvalidBlockSize
+	}
+	if marker == markerAPP1 || (marker == markerAPP11 && s.p.opts.StripC2PA) || s.p.handles(marker) {
+		s.need, s.whole = size+2, true
+		return
+	}
+	s.left = size - 2
+	return s.flush(4)
+}
+
+// segment decide whether to forward, replace or drop the segment in buf.
+func (s *Stream) segment() (err error) {
+	seg := segment{marker: binary.BigEndian.Uint16(s.buf), size: len(s.buf)}
+	switch {
+	case isExif(s.buf, seg):
+		s.grow -= seg.size
+		if b := seg.payload(s.buf); s.p.opts.keeps() && !s.kept && !emptyExif(b) {
+			s.kept = true
+			// unlike Processor the growth is limited as segments arrive
+			repl, _ := keptSegment(b, &s.p.opts, nil)
+			if s.p.opts.LimitGrowth && s.grow+len(repl) > s.p.opts.MaxGrowth {
+				repl = nil
+			}
+			if len(repl) > 0 {
+				if _, err = s.w.Write(repl); err != nil {
+					return
+				}
+				s.grow += len(repl)
+			}
+		}
+		s.buf = s.buf[:0]
+		return s.flush(0)
+	case seg.marker == markerAPP11 && s.p.opts.StripC2PA:
+		if j, ok := parseJUMBF(seg.payload(s.buf)); ok {
+			if j.isC2PA() {
+				if s.boxes == nil {
+					s.boxes = make(map[uint16]bool)
+				}
+				s.boxes[j.instance] = true
+			}
+			if s.boxes[j.instance] {
+				s.grow -= seg.size
+				s.buf = s.buf[:0]
+				return s.flush(0)
+			}
+		}
+	}
+	e, ok, err := s.p.handle(s.buf, seg)
+	switch {
+	case err != nil:
+		return
+	case !ok:
+		return s.flush(len(s.buf))
+	}
+	if s.grow += len(e.repl) - seg.size; s.p.opts.LimitGrowth && s.grow > s.p.opts.MaxGrowth {
+		return ErrGrowthExceeded
+	}
+	if len(e.repl) > 0 {
+		if _, err = s.w.Write(e.repl); err != nil {
+			return
+		}
+	}
+	s.buf = s.buf[:0]
+	return s.flush(0)
+}
+
+// flush forward the first n bytes of buf, keep the rest and wait for the
+// next segment header.
+func (s *Stream) flush(n int) (err error) {
+	if n > 0 {
+		if _, err = s.w.Write(s.buf[:n]); err != nil {
+			return
+		}
+	}
+	s.buf = append(s.buf[:0], s.buf[n:]...)
+	s.need, s.whole = 4, false
+	if s.done {
+		_, err = s.w.Write(s.buf)
+		s.buf = s.buf[:0]
+	}
+	return
+}
@@ -0,0 +1,96 @@
+package exif
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestStream(t *testing.T) {
+	for _, name := range []string{"exif_bigEndian.jpg", "exif_littleEndian.jpg", "jfif_bigEndian.jpg"} {
+		src, err := InsertSegment(readFixture(t, name), 0xeb, testJUMBF(3, "c2pa", "c2pa"), AfterAPPn)
+		if err != nil {
+			t.Fatalf("InsertSegment error(%v)", err)
+		}
+		for _, o := range []Options{{}, {KeepOrientation: true, StripC2PA: true}} {
+			p := NewProcessor(o)
+			want, err := p.Process(src)
+			if err != nil {
+				t.Fatalf("Process(%s) error(%v)", name, err)
+			}
+			for _, chunk := range []int{1, 3, 7, 4096} {
+				var w bytes.Buffer
+				s := p.NewStream(&w)
+				done := false
+				for in := src; len(in) > 0; {
+					n := chunk
+					if n > len(in) {
+						n = len(in)
+					}
+					consumed, d, err := s.Feed(in[:n])
+					if err != nil || consumed != n {
+						t.Fatalf("Feed(%s) = %d, %v, want %d", name, consumed, err, n)
+					}
+					done, in = d, in[n:]
+				}
+				if !done || !bytes.Equal(w.Bytes(), want) {
+					t.Fatalf("Stream(%s, %+v, chunk %d) done %v, output differ from Process", name, o, chunk, done)
+				}
+			}
+		}
+	}
+	if _, _, err := NewProcessor(Options{}).NewStream(new(bytes.Buffer)).Feed([]byte("GIF89a")); err != ErrMissSOIMarker {
+		t.Fatalf("Feed(gif) error(%v), want %v", err, ErrMissSOIMarker)
+	}
+}
+
+// countWriter count the bytes written to it and keep the first ones.
+type countWriter struct {
+	n    int64
+	head []byte
+}
+
+func (w *countWriter) Write(p []byte) (int, error) {
+	if len(w.head) < 4096 {
+		w.head = append(w.head, p[:min(len(p), 4096-len(w.head))]...)
+	}
+	w.n += int64(len(p))
+	return len(p), nil
+}
+
+func TestStreamLargeFile(t *testing.T) {
+	if os.Getenv("EXIF_LARGE_TESTS") == "" {
+		t.Skip("set EXIF_LARGE_TESTS to read a sparse 5GB file")
+	}
+	src := readFixture(t, filename)
+	f, err := os.Create(filepath.Join(t.TempDir(), "motion.jpg"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	// an image followed by a sparse video larger than the uint32 range
+	const size = 5 << 30
+	if _, err = f.Write(src); err != nil {
+		t.Fatal(err)
+	}
+	if err = f.Truncate(size); err != nil {
+		t.Skipf("sparse file not supported: %v", err)
+	}
+	if _, err = f.Seek(0, io.SeekStart); err != nil {
+		t.Fatal(err)
+	}
+	want, _ := NewProcessor(Options{}).Process(src)
+	var w countWriter
+	n, err := NewProcessor(Options{}).NewStream(&w).ReadFrom(f)
+	if err != nil || n != size {
+		t.Fatalf("ReadFrom = %d, %v, want %d", n, err, int64(size))
+	}
+	if removed := int64(len(src) - len(want)); w.n != size-removed {
+		t.Fatalf("wrote %d bytes, want %d", w.n, size-removed)
+	}
+	if !bytes.HasPrefix(want, w.head[:min(len(want), len(w.head))]) {
+		t.Fatalf("output differ from Process")
+	}
+}
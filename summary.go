@@ -0,0 +1,67 @@
+package exif
+
+import (
+	"bytes"
+	"strings"
+	"time"
+
+	"github.com/lkzz/exif/exiftag"
+)
+
+// editors are software names denoting an edited image.
+var editors = []string{"photoshop", "lightroom", "gimp", "snapseed", "pixelmator", "affinity", "paint.net", "picasa", "canva", "facetune"}
+
+// Provenance is a high level summary of where an image come from, for
+// triaging user uploads.
+type Provenance struct {
+	Make, Model string
+	Software    string
+	// CaptureTime is DateTimeOriginal, zero if unknown.
+	CaptureTime time.Time
+	HasExif     bool
+	HasGPS      bool
+	HasXMP      bool
+	HasC2PA     bool
+	// Edits are the hints the image was edited, like "software: Adobe
+	// Photoshop 25.0" or "xmp history".
+	Edits []string
+}
+
+// Summary return the provenance of the jpeg image in, an image without
+// metadata has an empty summary.
+func Summary(in []byte) (p Provenance, err error) {
+	defer recoverCorrupt(&err)
+	if p.HasC2PA, err = HasC2PA(in); err != nil {
+		return
+	}
+	if packet, xerr := xmpPacket(in); xerr == nil {
+		p.HasXMP = true
+		if bytes.Contains(packet, []byte("xmpMM:History")) {
+			p.Edits = append(p.Edits, "xmp history")
+		}
+		if bytes.Contains(packet, []byte("xmlns:photoshop")) {
+			p.Edits = append(p.Edits, "photoshop xmp")
+		}
+	}
+	e, derr := Decode(in)
+	if derr != nil {
+		return
+	}
+	p.HasExif = true
+	p.Make, _ = Get[string](e, exiftag.Make)
+	p.Model, _ = Get[string](e, exiftag.Model)
+	p.Software, _ = Get[string](e, exiftag.Software)
+	p.CaptureTime, _ = Get[time.Time](e, exiftag.DateTimeOriginal)
+	p.HasGPS = len(e.t.gps) > 0
+	software := strings.ToLower(p.Software)
+	for _, name := range editors {
+		if strings.Contains(software, name) {
+			p.Edits = append(p.Edits, "software: "+p.Software)
+			break
+		}
+	}
+	if modified, merr := Get[time.Time](e, exiftag.DateTime); merr == nil && !p.CaptureTime.IsZero() && modified.After(p.CaptureTime) {
+		p.Edits = append(p.Edits, "modified after capture")
+	}
+	return
+}
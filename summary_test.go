@@ -0,0 +1,38 @@
+package exif
+
+import (
+	"image"
+	"testing"
+	"time"
+)
+
+func TestSummary(t *testing.T) {
+	p, err := Summary(readFixture(t, filename))
+	if err != nil {
+		t.Fatalf("Summary error(%v)", err)
+	}
+	if !p.HasExif || p.Make == "" || p.CaptureTime.IsZero() {
+		t.Fatalf("Summary = %+v", p)
+	}
+	src, _ := StripAll(readFixture(t, filename))
+	if p, err = Summary(src); err != nil || p.HasExif || len(p.Edits) != 0 {
+		t.Fatalf("Summary(stripped) = %+v, %v", p, err)
+	}
+	payload, err := Build(image.Config{Width: 1, Height: 1}, Params{
+		DateTime: time.Date(2021, 5, 1, 12, 0, 0, 0, time.UTC),
+		Software: "Adobe Photoshop 25.0",
+		GPS:      &GPS{Latitude: 1, Longitude: 2},
+	})
+	if err != nil {
+		t.Fatalf("Build error(%v)", err)
+	}
+	edited, _ := InsertSegment(src, 0xe1, payload, AfterAPP0)
+	xmp := append(append([]byte(nil), xmpPrefix...), `<x:xmpmeta><rdf:Description xmlns:photoshop="http://ns.adobe.com/photoshop/1.0/"><xmpMM:History/></rdf:Description></x:xmpmeta>`...)
+	edited, _ = InsertSegment(edited, 0xe1, xmp, AfterAPPn)
+	if p, err = Summary(edited); err != nil {
+		t.Fatalf("Summary error(%v)", err)
+	}
+	if !p.HasGPS || !p.HasXMP || len(p.Edits) != 3 || p.Edits[2] != "software: Adobe Photoshop 25.0" {
+		t.Fatalf("Summary(edited) = %+v", p)
+	}
+}
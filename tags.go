@@ -0,0 +1,36 @@
+package exif
+
+import (
+	"encoding/binary"
+	"iter"
+)
+
+// TagID identify a tag in the IFD holding it: "ifd0", "exif", "gps",
+// "interop" or "ifd1".
+type TagID struct {
+	IFD string
+	Tag uint16
+}
+
+// Tag is a decoded tag, Value is its raw value in Order and must not be modified.
+type Tag struct {
+	Type  uint16 // tiff data format, like 3 for SHORT
+	Count uint32
+	Value []byte
+	Order binary.ByteOrder
+}
+
+// Tags return an iterator over the tags of e, IFD0 and its sub IFDs first,
+// then IFD1, each in the order they were decoded.
+func (e *Exif) Tags() iter.Seq2[TagID, Tag] {
+	t := e.block()
+	return func(yield func(TagID, Tag) bool) {
+		for _, ifd := range t.ifds() {
+			for _, en := range ifd.es {
+				if !yield(TagID{IFD: ifd.name, Tag: en.tag}, Tag{Type: en.typ, Count: en.count, Value: en.value, Order: t.order}) {
+					return
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,25 @@
+package exif
+
+import "testing"
+
+func TestTags(t *testing.T) {
+	e, err := Decode(readFixture(t, filename))
+	if err != nil {
+		t.Fatalf("Decode error(%v)", err)
+	}
+	n := 0
+	ifds := make(map[string]int)
+	for id, tag := range e.Tags() {
+		n++
+		ifds[id.IFD]++
+		if id == (TagID{IFD: "ifd0", Tag: orientationTag}) && tag.Order.Uint16(tag.Value) != 6 {
+			t.Fatalf("orientation = %d, want 6", tag.Order.Uint16(tag.Value))
+		}
+	}
+	if want := len(e.t.ifd0) + len(e.t.exif) + len(e.t.gps) + len(e.t.interop) + len(e.t.ifd1); n != want || ifds["exif"] != len(e.t.exif) {
+		t.Fatalf("Tags yield %d tags, want %d", n, want)
+	}
+	for range e.Tags() {
+		break // stopping early must not panic
+	}
+}
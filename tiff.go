@@ -0,0 +1,254 @@
+package exif
+
+import "encoding/binary"
+
+// tag ids used by the tiff reader
+const (
+	exifIFDTag      = 0x8769
+	gpsIFDTag       = 0x8825
+	interopIFDTag   = 0xa005
+	thumbOffsetTag  = 0x0201
+	thumbLengthTag  = 0x0202
+	stripOffsetsTag = 0x0111
+)
+
+// tiff data formats
+const (
+	typeByte      = 1
+	typeASCII     = 2
+	typeShort     = 3
+	typeLong      = 4
+	typeRational  = 5
+	typeSByte     = 6
+	typeUndefined = 7
+	typeSShort    = 8
+	typeSLong     = 9
+	typeSRational = 10
+	typeFloat     = 11
+	typeDouble    = 12
+)
+
+// typeSize is the byte size of one component of each data format.
+var typeSize = [...]int{0, 1, 1, 2, 4, 8, 1, 1, 2, 4, 8, 4, 8}
+
+// exifPrefix is the identifier at the start of an exif APP1 payload.
+var exifPrefix = []byte{0x45, 0x78, 0x69, 0x66, 0x00, 0x00}
+
+// entry is one IFD entry, value is the raw tag value in the block byte order.
+type entry struct {
+	tag   uint16
+	typ   uint16
+	count uint32
+	value []byte
+	// offset is the offset of value in the block it was read from, 0 if inline.
+	offset uint32
+	// fixed is the offset encode write value at, 0 to lay it out with the IFD.
+	fixed uint32
+}
+
+// byteOrder is implemented by binary.BigEndian and binary.LittleEndian.
+type byteOrder interface {
+	binary.ByteOrder
+	binary.AppendByteOrder
+}
+
+// tiff is a parsed exif tiff block.
+type tiff struct {
+	order   byteOrder
+	ifd0    []entry
+	exif    []entry
+	gps     []entry
+	interop []entry
+	ifd1    []entry
+	// thumbnail is the jpeg thumbnail pointed to by ifd1.
+	thumbnail []byte
+	// layout is used by encode.
+	layout Layout
+	// minimal is the block kept by the strip functions, encoded without
+	// the version tags.
+	minimal bool
+}
+
+// ifd is an IFD of a tiff block and its name.
+type ifd struct {
+	name string // "ifd0", "exif", "gps", "interop" or "ifd1"
+	es   []entry
+}
+
+// ifds return the IFDs of t, main image first.
+func (t *tiff) ifds() []ifd {
+	return []ifd{{"ifd0", t.ifd0}, {"exif", t.exif}, {"gps", t.gps}, {"interop", t.interop}, {"ifd1", t.ifd1}}
+}
+
+// exifBlock return the tiff block of the first exif APP1 segment.
+func exifBlock(in []byte) (b []byte, err error) {
+	if b, err = findSegment(in, markerAPP1, exifPrefix); err == nil && b == nil {
+		err = ErrNoExif
+	}
+	return
+}
+
+// parseTIFF parse IFD0, IFD1 and the exif, gps and interop sub IFDs of b.
+func parseTIFF(b []byte) (t *tiff, err error) {
+	var next uint32
+	if t, next, err = parseIFD0(b); err != nil {
+		return
+	}
+	if t.exif, err = t.readSubIFD(b, t.ifd0, exifIFDTag); err != nil {
+		return
+	}
+	if t.gps, err = t.readSubIFD(b, t.ifd0, gpsIFDTag); err != nil {
+		return
+	}
+	if t.interop, err = t.readSubIFD(b, t.exif, interopIFDTag); err != nil {
+		return
+	}
+	if next == 0 {
+		return
+	}
+	if t.ifd1, _, err = t.readIFD(b, next); err != nil {
+		return
+	}
+	off, ok := t.uint(t.ifd1, thumbOffsetTag)
+	size, sok := t.uint(t.ifd1, thumbLengthTag)
+	if end := int64(off) + int64(size); ok && sok && end <= int64(len(b)) {
+		t.thumbnail = b[off:end] // off+size may overflow uint32
+	}
+	return
+}
+
+// parseIFD0 parse the header and IFD0 of b, next is the offset of IFD1.
+func parseIFD0(b []byte) (t *tiff, next uint32, err error) {
+	if len(b) < 8 {
+		err = ErrInvalidHeader
+		return
+	}
+	t = new(tiff)
+	switch binary.BigEndian.Uint16(b) {
+	case byteOrderBE:
+		t.order = binary.BigEndian
+	case byteOrderLE:
+		t.order = binary.LittleEndian
+	default:
+		err = ErrInvalidOrderFlag
+		return
+	}
+	if t.order.Uint16(b[2:]) != byteOrderExt {
+		err = ErrInvalidOrderFlag
+		return
+	}
+	t.ifd0, next, err = t.readIFD(b, t.order.Uint32(b[4:]))
+	return
+}
+
+// readSubIFD read the IFD pointed to by tag in es, if any.
+func (t *tiff) readSubIFD(b []byte, es []entry, tag uint16) (sub []entry, err error) {
+	off, ok := t.uint(es, tag)
+	if !ok {
+		return
+	}
+	sub, _, err = t.readIFD(b, off)
+	return
+}
+
+// readIFD read the IFD at offset off and return its entries and the offset of the next IFD.
+func (t *tiff) readIFD(b []byte, off uint32) (es []entry, next uint32, err error) {
+	if off < 8 || int64(off)+2 > int64(len(b)) {
+		err = ErrInvalidOffset
+		return
+	}
+	n := int(t.order.Uint16(b[off:]))
+	p := int(off) + 2
+	if p+n*12 > len(b) {
+		err = ErrInvalidOffset
+		return
+	}
+	es = make([]entry, 0, n)
+	for i := 0; i < n; i, p = i+1, p+12 {
+		e := entry{
+			tag:   t.order.Uint16(b[p:]),
+			typ:   t.order.Uint16(b[p+2:]),
+			count: t.order.Uint32(b[p+4:]),
+		}
+		if e.typ == 0 || int(e.typ) >= len(typeSize) { // unknown data format, skip it
+			continue
+		}
+		size := int64(typeSize[e.typ]) * int64(e.count)
+		if size <= 4 {
+			e.value = b[p+8 : p+8+int(size)]
+		} else {
+			vo := int64(t.order.Uint32(b[p+8:]))
+			if vo+size > int64(len(b)) {
+				err = ErrInvalidTagValue
+				return
+			}
+			e.value, e.offset = b[vo:vo+size], uint32(vo)
+		}
+		es = append(es, e)
+	}
+	if p+4 <= len(b) {
+		next = t.order.Uint32(b[p:])
+	}
+	return
+}
+
+// lookup return the entry of tag in es.
+func lookup(es []entry, tag uint16) (e entry, ok bool) {
+	for _, e = range es {
+		if e.tag == tag {
+			ok = true
+			return
+		}
+	}
+	return
+}
+
+// uint return the first component of an integer tag.
+func (t *tiff) uint(es []entry, tag uint16) (v uint32, ok bool) {
+	var e entry
+	if e, ok = lookup(es, tag); !ok || e.count == 0 {
+		ok = false
+		return
+	}
+	switch e.typ {
+	case typeByte, typeUndefined:
+		v = uint32(e.value[0])
+	case typeShort:
+		v = uint32(t.order.Uint16(e.value))
+	case typeLong:
+		v = t.order.Uint32(e.value)
+	default:
+		ok = false
+	}
+	return
+}
+
+// float return the first component of a numeric tag as float64.
+func (t *tiff) float(es []entry, tag uint16) (v float64, ok bool) {
+	var e entry
+	if e, ok = lookup(es, tag); !ok || e.count == 0 {
+		ok = false
+		return
+	}
+	switch e.typ {
+	case typeRational:
+		num, den := t.order.Uint32(e.value), t.order.Uint32(e.value[4:])
+		if den == 0 {
+			ok = false
+			return
+		}
+		v = float64(num) / float64(den)
+	case typeSRational:
+		num, den := int32(t.order.Uint32(e.value)), int32(t.order.Uint32(e.value[4:]))
+		if den == 0 {
+			ok = false
+			return
+		}
+		v = float64(num) / float64(den)
+	default:
+		var u uint32
+		u, ok = t.uint(es, tag)
+		v = float64(u)
+	}
+	return
+}
@@ -0,0 +1,222 @@
+package exif
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/lkzz/exif/exiftag"
+)
+
+// ErrUnknownTag is returned when editing a tag which is not defined by EXIF 2.32.
+var ErrUnknownTag = errors.New("unknown tag")
+
+// Tx is an exif editing transaction, the changes are validated together and
+// applied by Commit in a single rewrite of the image.
+type Tx struct {
+	in    []byte
+	ops   []txOp
+	agent string // software agent of the xmp history event, none if empty
+}
+
+// txOp is a pending change.
+type txOp struct {
+	id  exiftag.ID
+	v   interface{}
+	del bool // remove the tag
+}
+
+// Edit start editing the exif of the jpeg image in.
+func Edit(in []byte) *Tx {
+	return &Tx{in: in}
+}
+
+// Set set the tag id to v, converted to the data format of the tag:
+// uint16, uint32 and int for integer tags, float64 for rational tags,
+// string for ASCII tags, []byte for UNDEFINED and BYTE tags, and
+// time.Time for date time tags, which also set their offset time tag.
+func (tx *Tx) Set(id exiftag.ID, v interface{}) *Tx {
+	tx.ops = append(tx.ops, txOp{id: id, v: v})
+	return tx
+}
+
+// Delete remove the tag id.
+func (tx *Tx) Delete(id exiftag.ID) *Tx {
+	tx.ops = append(tx.ops, txOp{id: id, del: true})
+	return tx
+}
+
+// History make Commit append an event to the xmpMM:History of the xmp
+// packet, the "saved" action on "/metadata" by the software agent at the
+// time of the commit, like Adobe tools do, so asset management systems see
+// the edit. A packet is added to images without xmp.
+func (tx *Tx) History(agent string) *Tx {
+	tx.agent = agent
+	return tx
+}
+
+// Rollback discard the pending changes and history event.
+func (tx *Tx) Rollback() {
+	tx.ops, tx.agent = nil, ""
+}
+
+// Commit validate the changes and return the image with them applied. If
+// any change is invalid, the errors of all of them are returned and the
+// changes are rolled back. Without changes the exif is left untouched, an
+// image without exif is not given one by a History only commit.
+func (tx *Tx) Commit() (out []byte, err error) {
+	defer recoverCorrupt(&err)
+	defer tx.Rollback()
+	out = tx.in
+	if len(tx.ops) > 0 { // the exif is left untouched by a history only commit
+		if out, err = tx.commitExif(); err != nil {
+			return
+		}
+	}
+	if tx.agent == "" {
+		return
+	}
+	packet, _ := xmpPacket(out) // start from xmpTemplate if out has none
+	return setXMPPacket(out, appendXMPHistory(packet, "saved", tx.agent, time.Now()))
+}
+
+// commitExif return tx.in with the changes applied to its exif, an exif
+// segment is added to the images without.
+func (tx *Tx) commitExif() (out []byte, err error) {
+	e, err := Decode(tx.in)
+	if err == ErrNoExif {
+		e, err = &Exif{}, nil
+	}
+	if err != nil {
+		return
+	}
+	t := *e.block() // the IFDs are copied before being modified
+	t.layout.KeepMakerNote = true
+	var errs []error
+	for _, op := range tx.ops {
+		if oerr := t.apply(op); oerr != nil {
+			errs = append(errs, fmt.Errorf("tag %#x: %w", uint32(op.id), oerr))
+		}
+	}
+	if err = errors.Join(errs...); err != nil {
+		return
+	}
+	var payload []byte
+	if payload, _, err = t.app1(); err != nil {
+		return
+	}
+	return SetRawExif(tx.in, payload)
+}
+
+// apply apply op to t after validating it against the specification.
+func (t *tiff) apply(op txOp) (err error) {
+	es, spec, ok := t.ifdOf(op.id)
+	if !ok {
+		return ErrUnknownTag
+	}
+	tag := uint16(op.id)
+	*es = remove(*es, tag)
+	if op.del {
+		return
+	}
+	var e entry
+	if e, err = t.convert(tag, spec, op.v); err != nil {
+		return
+	}
+	if why := t.check(e, spec); why != "" {
+		return fmt.Errorf("%w: %s", ErrInvalidTagValue, why)
+	}
+	*es = append(*es, e)
+	if v, isTime := op.v.(time.Time); isTime {
+		if off, ok := offsetTags[op.id]; ok {
+			t.exif = append(remove(t.exif, off), t.ascii(off, v.Format(offsetTimeLayout)))
+		}
+	}
+	return
+}
+
+// ifdOf return the IFD holding id and its specification.
+func (t *tiff) ifdOf(id exiftag.ID) (es *[]entry, spec tagSpec, ok bool) {
+	tag := uint16(id)
+	switch id &^ 0xffff {
+	case exiftag.GPS:
+		es = &t.gps
+		spec, ok = gpsSpecs[tag]
+	case exiftag.Interop:
+		es = &t.interop
+		spec, ok = interopSpecs[tag]
+	case 0:
+		if spec, ok = tiffSpecs[tag]; ok {
+			es = &t.ifd0
+		} else if spec, ok = exifSpecs[tag]; ok {
+			es = &t.exif
+		}
+	}
+	return
+}
+
+// convert return the entry of tag holding v in a data format allowed by spec.
+func (t *tiff) convert(tag uint16, spec tagSpec, v interface{}) (e entry, err error) {
+	switch x := v.(type) {
+	case uint16:
+		return t.integerEntry(tag, spec, uint64(x))
+	case uint32:
+		return t.integerEntry(tag, spec, uint64(x))
+	case int:
+		if x < 0 {
+			err = ErrInvalidTagValue
+			return
+		}
+		return t.integerEntry(tag, spec, uint64(x))
+	case float64:
+		return t.rationalEntry(tag, spec, x)
+	case string:
+		e = t.ascii(tag, x)
+	case []byte:
+		if e = t.undefined(tag, x); spec.types&fB != 0 {
+			e.typ = typeByte
+		}
+	case time.Time:
+		e = t.ascii(tag, x.Format(dateTimeLayout))
+	default:
+		err = ErrInvalidTagType
+	}
+	return
+}
+
+// integerEntry return a SHORT entry, or LONG if spec do not allow SHORT.
+func (t *tiff) integerEntry(tag uint16, spec tagSpec, v uint64) (e entry, err error) {
+	switch {
+	case spec.types&fS != 0 && v <= math.MaxUint16:
+		e = t.shorts(tag, uint16(v))
+	case spec.types&fL != 0 && v <= math.MaxUint32:
+		e = t.longs(tag, uint32(v))
+	case spec.types&fB != 0 && v <= math.MaxUint8:
+		e = t.bytes(tag, byte(v))
+	case spec.types&(fS|fL|fB) != 0:
+		err = ErrInvalidTagValue
+	default:
+		err = ErrInvalidTagType
+	}
+	return
+}
+
+// rationalEntry return a RATIONAL or SRATIONAL entry approximating v.
+func (t *tiff) rationalEntry(tag uint16, spec tagSpec, v float64) (e entry, err error) {
+	const den = 10000
+	switch {
+	case math.IsNaN(v) || math.IsInf(v, 0):
+		err = ErrInvalidTagValue
+	case spec.types&fR != 0 && v >= 0 && v*den <= math.MaxUint32:
+		e = t.rationals(tag, uint32(math.Round(v*den)), den)
+	case spec.types&fSR != 0 && math.Abs(v*den) <= math.MaxInt32:
+		e = t.rationals(tag, uint32(int32(math.Round(v*den))), den)
+		e.typ = typeSRational
+	case spec.types&(fR|fSR) != 0:
+		err = ErrInvalidTagValue
+	default:
+		err = ErrInvalidTagType
+	}
+	return
+}
@@ -0,0 +1,145 @@
+package exif
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"io"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lkzz/exif/exiftag"
+)
+
+func TestTx(t *testing.T) {
+	in, err := os.ReadFile("testdata/jfif_bigEndian.jpg")
+	if err != nil {
+		t.Fatalf("ReadFile error(%v)", err)
+	}
+	when := time.Date(2021, 5, 1, 12, 30, 0, 0, time.FixedZone("", -5*3600))
+	out, err := Edit(in).
+		Set(exiftag.Orientation, 3).
+		Set(exiftag.Artist, "jane").
+		Set(exiftag.DateTimeOriginal, when).
+		Set(exiftag.ExposureBiasValue, -0.5).
+		Delete(exiftag.Software).
+		Commit()
+	if err != nil {
+		t.Fatalf("Commit error(%v)", err)
+	}
+	e, err := Decode(out)
+	if err != nil {
+		t.Fatalf("Decode error(%v)", err)
+	}
+	if o, err := Get[uint16](e, exiftag.Orientation); err != nil || o != 3 {
+		t.Fatalf("Orientation = %d, %v", o, err)
+	}
+	if a, err := Get[string](e, exiftag.Artist); err != nil || a != "jane" {
+		t.Fatalf("Artist = %q, %v", a, err)
+	}
+	if dt, err := Get[time.Time](e, exiftag.DateTimeOriginal); err != nil || !dt.Equal(when) {
+		t.Fatalf("DateTimeOriginal = %v, %v, want %v", dt, err, when)
+	}
+	if f, err := Get[float64](e, exiftag.ExposureBiasValue); err != nil || f != -0.5 {
+		t.Fatalf("ExposureBiasValue = %v, %v", f, err)
+	}
+	if _, err := Get[string](e, exiftag.Software); err != ErrTagNotFound {
+		t.Fatalf("Software error(%v), want ErrTagNotFound", err)
+	}
+}
+
+func TestTxInvalid(t *testing.T) {
+	in, err := os.ReadFile("testdata/jfif_bigEndian.jpg")
+	if err != nil {
+		t.Fatalf("ReadFile error(%v)", err)
+	}
+	orig := append([]byte(nil), in...)
+	tx := Edit(in).
+		Set(exiftag.Artist, "jane").
+		Set(exiftag.Orientation, 9).
+		Set(exiftag.ID(0xbeef), 1).
+		Set(exiftag.Make, 1.5)
+	out, err := tx.Commit()
+	if out != nil || err == nil {
+		t.Fatalf("Commit = %d bytes, %v, want error", len(out), err)
+	}
+	for _, want := range []error{ErrInvalidTagValue, ErrUnknownTag, ErrInvalidTagType} {
+		if !errors.Is(err, want) {
+			t.Errorf("Commit error(%v) do not report %v", err, want)
+		}
+	}
+	if !bytes.Equal(in, orig) {
+		t.Fatalf("Commit modified the input")
+	}
+	// the failed changes were rolled back
+	if out, err = tx.Set(exiftag.Artist, "joe").Commit(); err != nil {
+		t.Fatalf("Commit error(%v)", err)
+	}
+	e, _ := Decode(out)
+	if o, _ := Get[uint16](e, exiftag.Orientation); o == 9 {
+		t.Fatalf("Orientation 9 was applied")
+	}
+}
+
+func TestTxNoExif(t *testing.T) {
+	in, err := os.ReadFile("testdata/jfif_bigEndian.jpg")
+	if err != nil {
+		t.Fatalf("ReadFile error(%v)", err)
+	}
+	if in, err = StripAll(in); err != nil {
+		t.Fatalf("StripAll error(%v)", err)
+	}
+	out, err := Edit(in).Set(exiftag.GPSAltitude, 12.5).Commit()
+	if err != nil {
+		t.Fatalf("Commit error(%v)", err)
+	}
+	e, err := Decode(out)
+	if err != nil {
+		t.Fatalf("Decode error(%v)", err)
+	}
+	if f, err := Get[float64](e, exiftag.GPSAltitude); err != nil || f != 12.5 {
+		t.Fatalf("GPSAltitude = %v, %v", f, err)
+	}
+}
+
+func TestTxHistory(t *testing.T) {
+	out := readFixture(t, filename)
+	for _, agent := range []string{"exif-edit 1.0", `studio "2"`} {
+		var err error
+		if out, err = Edit(out).Set(exiftag.Artist, "jane").History(agent).Commit(); err != nil {
+			t.Fatalf("Commit(%s) error(%v)", agent, err)
+		}
+	}
+	packet, err := xmpPacket(out)
+	if err != nil {
+		t.Fatalf("xmpPacket error(%v)", err)
+	}
+	s := string(packet)
+	if strings.Count(s, `stEvt:action="saved"`) != 2 || strings.Count(s, "xmlns:stEvt=") != 1 || strings.Count(s, "xmlns:xmpMM=") != 1 ||
+		!strings.Contains(s, `stEvt:softwareAgent="exif-edit 1.0"`) || !strings.Contains(s, `stEvt:softwareAgent="studio &#34;2&#34;"`) {
+		t.Fatalf("xmp packet %s, want two history events", s)
+	}
+	for d := xml.NewDecoder(strings.NewReader(s)); ; {
+		if _, err = d.Token(); err == io.EOF {
+			break
+		} else if err != nil {
+			t.Fatalf("xmp packet is not well formed: %v", err)
+		}
+	}
+	if a, err := Get[string](mustDecode(t, out), exiftag.Artist); err != nil || a != "jane" {
+		t.Fatalf("Artist = %q, error(%v)", a, err)
+	}
+	// a history only commit does not add an exif segment
+	none, _ := StripAll(readFixture(t, filename))
+	if out, err = Edit(none).History("exif-edit 1.0").Commit(); err != nil {
+		t.Fatalf("Commit error(%v)", err)
+	}
+	if _, err = Decode(out); err != ErrNoExif {
+		t.Fatalf("Decode error(%v), want ErrNoExif", err)
+	}
+	if packet, _ = xmpPacket(out); !strings.Contains(string(packet), `stEvt:softwareAgent="exif-edit 1.0"`) {
+		t.Fatalf("xmp packet %s, want the history event", packet)
+	}
+}
@@ -0,0 +1,103 @@
+package exif
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+
+	"github.com/lkzz/exif/exiftag"
+)
+
+// imageUniqueIDTag is the exif tag holding a 128 bit identifier of the image
+// as 32 hexadecimal digits.
+const imageUniqueIDTag = 0xa420
+
+// ContentID return an ImageUniqueID derived from the image data of in, from
+// the first SOS on, so copies of an image differing only by their metadata
+// get the same id. It can be used as Options.UniqueID.
+func ContentID(in []byte) string {
+	if _, end, err := readSegments(in); err == nil {
+		in = in[end:]
+	}
+	sum := sha256.Sum256(in)
+	return hex.EncodeToString(sum[:16])
+}
+
+// RandomID return a random ImageUniqueID, a version 4 UUID without dashes.
+// It can be used as Options.UniqueID.
+func RandomID([]byte) string {
+	var u [16]byte
+	rand.Read(u[:]) // never return an error
+	u[6] = u[6]&0x0f | 0x40
+	u[8] = u[8]&0x3f | 0x80
+	return hex.EncodeToString(u[:])
+}
+
+// keptSegment return an APP1 segment holding the tags of the exif payload
+// b that o keep: the orientation, the KeepTags and the fuzzed location, and
+// the ImageUniqueID if id is not nil, which is called if b has none. seg is
+// nil if no tag is kept, oriented report whether the orientation was.
+func keptSegment(b []byte, o *Options, id func() string) (seg []byte, oriented bool) {
+	var src *tiff
+	if !emptyExif(b) {
+		if len(o.KeepTags) > 0 || o.FuzzGPS > 0 {
+			src, _ = parseTIFF(b[len(exifPrefix):])
+		} else {
+			src, _, _ = parseIFD0(b[len(exifPrefix):])
+		}
+	}
+	kept := &tiff{order: binary.BigEndian, minimal: true}
+	if src != nil {
+		kept.order = src.order
+		if e, ok := lookup(src.ifd0, orientationTag); o.KeepOrientation && ok {
+			kept.ifd0, oriented = []entry{e}, true
+		}
+		for _, id := range o.KeepTags {
+			kept.keep(src, id)
+		}
+		if g, err := (&Exif{t: src}).Location(); o.FuzzGPS > 0 && err == nil {
+			for _, e := range kept.location(GPS{Latitude: g.Latitude, Longitude: g.Longitude}.fuzz(o.FuzzGPS)) {
+				kept.gps = append(remove(kept.gps, e.tag), e)
+			}
+		}
+	}
+	if id != nil {
+		var sub []entry
+		if src != nil {
+			sub, _ = src.readSubIFD(b[len(exifPrefix):], src.ifd0, exifIFDTag)
+		}
+		e, ok := lookup(sub, imageUniqueIDTag)
+		if !ok || e.typ != typeASCII {
+			e = kept.ascii(imageUniqueIDTag, id())
+		}
+		kept.exif = append(remove(kept.exif, imageUniqueIDTag), e)
+	}
+	if kept.ifd0 == nil && kept.exif == nil && kept.gps == nil {
+		return
+	}
+	payload, _, err := kept.app1()
+	if err != nil {
+		return nil, false
+	}
+	seg = append([]byte{0xff, 0xe1, byte((len(payload) + 2) >> 8), byte(len(payload) + 2)}, payload...)
+	return
+}
+
+// keep copy the entry of id from src to t, if src has it. IFD0 tags are
+// looked up in IFD0 then in the exif IFD, like lookupID.
+func (t *tiff) keep(src *tiff, id exiftag.ID) {
+	tag := uint16(id)
+	switch id &^ 0xffff {
+	case exiftag.GPS:
+		if e, ok := lookup(src.gps, tag); ok {
+			t.gps = append(remove(t.gps, tag), e)
+		}
+	case 0:
+		if e, ok := lookup(src.ifd0, tag); ok {
+			t.ifd0 = append(remove(t.ifd0, tag), e)
+		} else if e, ok = lookup(src.exif, tag); ok {
+			t.exif = append(remove(t.exif, tag), e)
+		}
+	}
+}
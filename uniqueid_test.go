@@ -0,0 +1,77 @@
+package exif
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/lkzz/exif/exiftag"
+)
+
+var uniqueIDPattern = regexp.MustCompile(`^[0-9a-f]{32}$`)
+
+func TestProcessorUniqueID(t *testing.T) {
+	in := readFixture(t, "empty_exif.jpg")
+	p := NewProcessor(Options{KeepOrientation: true, UniqueID: ContentID})
+	out, err := p.Process(in)
+	if err != nil {
+		t.Fatalf("Process error(%v)", err)
+	}
+	id, err := Get[string](mustDecode(t, out), exiftag.ImageUniqueID)
+	if err != nil || id != ContentID(in) || !uniqueIDPattern.MatchString(id) {
+		t.Fatalf("ImageUniqueID = %q, %v, want %q", id, err, ContentID(in))
+	}
+	// the id survive reprocessing and is shared by copies with other metadata
+	again, err := NewProcessor(Options{UniqueID: RandomID}).Process(out)
+	if err != nil {
+		t.Fatalf("Process error(%v)", err)
+	}
+	if got, _ := Get[string](mustDecode(t, again), exiftag.ImageUniqueID); got != id {
+		t.Fatalf("ImageUniqueID = %q after reprocessing, want %q", got, id)
+	}
+	if ContentID(out) != id {
+		t.Fatalf("ContentID changed with the metadata")
+	}
+	if out, err = NewProcessor(Options{UniqueID: RandomID}).Process(in); err != nil {
+		t.Fatalf("Process error(%v)", err)
+	}
+	if id, _ = Get[string](mustDecode(t, out), exiftag.ImageUniqueID); !uniqueIDPattern.MatchString(id) || id[12] != '4' {
+		t.Fatalf("RandomID = %q, want a version 4 UUID", id)
+	}
+}
+
+func TestProcessorUniqueIDKept(t *testing.T) {
+	in := readFixture(t, "exif_littleEndian.jpg")
+	want, err := Get[string](mustDecode(t, in), exiftag.ImageUniqueID)
+	if err != nil {
+		t.Fatalf("Get ImageUniqueID error(%v)", err)
+	}
+	orientation, _ := Get[uint16](mustDecode(t, in), exiftag.Orientation)
+	out, err := NewProcessor(Options{KeepOrientation: true, UniqueID: RandomID}).Process(in)
+	if err != nil {
+		t.Fatalf("Process error(%v)", err)
+	}
+	e := mustDecode(t, out)
+	if id, _ := Get[string](e, exiftag.ImageUniqueID); id != want {
+		t.Fatalf("ImageUniqueID = %q, want the existing %q", id, want)
+	}
+	if o, _ := Get[uint16](e, exiftag.Orientation); o != orientation {
+		t.Fatalf("Orientation = %d, want %d", o, orientation)
+	}
+	if _, err = Get[string](e, exiftag.Make); err != ErrTagNotFound {
+		t.Fatalf("Make error(%v), want it removed", err)
+	}
+	// the strip output is not given the version tags of the edits
+	for _, tag := range []uint16{exifVersionTag, flashpixVersionTag, componentsConfigurationTag} {
+		if _, ok := lookup(e.t.exif, tag); ok {
+			t.Fatalf("tag %#x added to the kept exif", tag)
+		}
+	}
+}
+
+func mustDecode(t *testing.T, in []byte) *Exif {
+	e, err := Decode(in)
+	if err != nil {
+		t.Fatalf("Decode error(%v)", err)
+	}
+	return e
+}
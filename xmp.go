@@ -0,0 +1,300 @@
+package exif
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"io"
+	"strings"
+	"time"
+)
+
+// xmpPrefix is the identifier at the start of a xmp APP1 payload.
+var xmpPrefix = []byte("http://ns.adobe.com/xap/1.0/\x00")
+
+// xmpExtPrefix is the identifier of the APP1 segments holding extended xmp.
+var xmpExtPrefix = []byte("http://ns.adobe.com/xmp/extension/\x00")
+
+// xmp errors
+var (
+	ErrNoXMP = errors.New("xmp not exist")
+)
+
+// XMPParser decode a raw xmp packet into properties keyed by their qualified
+// name (e.g. "dc:subject"), array properties holding one value per item.
+// Implement it to plug in a full RDF/XML parser.
+type XMPParser interface {
+	ParseXMP(packet []byte) (props map[string][]string, err error)
+}
+
+// XMPParserFunc adapt an ordinary function to XMPParser.
+type XMPParserFunc func(packet []byte) (map[string][]string, error)
+
+// ParseXMP call f(packet).
+func (f XMPParserFunc) ParseXMP(packet []byte) (map[string][]string, error) {
+	return f(packet)
+}
+
+// LightXMPParser is the built-in parser, it reads simple properties given as
+// attributes or elements of rdf:Description and the items of rdf:Bag, rdf:Seq
+// and rdf:Alt arrays, nested structures are ignored.
+var LightXMPParser XMPParser = XMPParserFunc(parseXMP)
+
+// XMP return the properties of the xmp packet of image, parsed by p.
+// LightXMPParser is used if p is nil.
+func XMP(in []byte, p XMPParser) (props map[string][]string, err error) {
+	defer recoverCorrupt(&err)
+	var packet []byte
+	if packet, err = xmpPacket(in); err != nil {
+		return
+	}
+	if p == nil {
+		p = LightXMPParser
+	}
+	callback(func() { props, err = p.ParseXMP(packet) })
+	return
+}
+
+// isXMP report whether s is a main or extended xmp APP1 segment.
+func isXMP(in []byte, s segment) bool {
+	if s.marker != markerAPP1 {
+		return false
+	}
+	p := s.payload(in)
+	return bytes.HasPrefix(p, xmpPrefix) || bytes.HasPrefix(p, xmpExtPrefix)
+}
+
+// xmpPacket return the xmp packet of the first xmp APP1 segment.
+func xmpPacket(in []byte) (b []byte, err error) {
+	if b, err = findSegment(in, markerAPP1, xmpPrefix); err == nil && b == nil {
+		err = ErrNoXMP
+	}
+	return
+}
+
+// parseXMP is the LightXMPParser implementation.
+func parseXMP(packet []byte) (props map[string][]string, err error) {
+	var (
+		d     = xml.NewDecoder(bytes.NewReader(bytes.Trim(packet, "\x00")))
+		depth int    // depth of the current element
+		descs []int  // depths of the open rdf:Description, nested ones are structures
+		prop  string // property being read
+		items int    // array items read for prop
+		inLi  bool
+		text  strings.Builder
+	)
+	d.Strict = false
+	props = make(map[string][]string)
+	for {
+		var tok xml.Token
+		if tok, err = d.RawToken(); err != nil {
+			if err == io.EOF {
+				err = nil
+			}
+			return
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			depth++
+			switch {
+			case isRDF(t.Name, "Description"):
+				descs, prop = append(descs, depth), ""
+				for _, a := range t.Attr {
+					if len(descs) == 1 && a.Name.Space != "" && a.Name.Space != "xmlns" && a.Name.Space != "rdf" {
+						props[qname(a.Name)] = append(props[qname(a.Name)], a.Value)
+					}
+				}
+			case prop != "" && isRDF(t.Name, "li"):
+				inLi = true
+				text.Reset()
+			case len(descs) == 1 && depth == descs[0]+1:
+				prop, items = qname(t.Name), 0
+				text.Reset()
+			}
+		case xml.CharData:
+			if prop != "" {
+				text.Write(t)
+			}
+		case xml.EndElement:
+			switch {
+			case inLi && isRDF(t.Name, "li"):
+				props[prop] = append(props[prop], strings.TrimSpace(text.String()))
+				inLi = false
+				items++
+			case prop != "" && len(descs) == 1 && depth == descs[0]+1:
+				if s := strings.TrimSpace(text.String()); items == 0 && s != "" {
+					props[prop] = append(props[prop], s)
+				}
+				prop = ""
+			case len(descs) > 0 && depth == descs[len(descs)-1]:
+				descs = descs[:len(descs)-1]
+			}
+			depth--
+		}
+	}
+}
+
+func isRDF(n xml.Name, local string) bool {
+	return n.Space == "rdf" && n.Local == local
+}
+
+func qname(n xml.Name) string {
+	if n.Space == "" {
+		return n.Local
+	}
+	return n.Space + ":" + n.Local
+}
+
+// xmpTemplate is the packet written to images without xmp.
+const xmpTemplate = "<?xpacket begin=\"\ufeff\" id=\"W5M0MpCehiHzreSzNTczkc9d\"?>" +
+	`<x:xmpmeta xmlns:x="adobe:ns:meta/"><rdf:RDF xmlns:rdf="http://www.w3.org/1999/02/22-rdf-syntax-ns#">` +
+	`<rdf:Description rdf:about=""/></rdf:RDF></x:xmpmeta><?xpacket end="w"?>`
+
+// setXMPArray return packet with the array property name, like "dc:subject",
+// replaced by an rdf array of kind, like "Bag", holding items. The property
+// is removed if items is empty, see setXMPProperty.
+func setXMPArray(packet []byte, name, uri, kind string, items []string) []byte {
+	if len(items) == 0 {
+		return setXMPProperty(packet, name, uri, "")
+	}
+	var prop strings.Builder
+	prop.WriteString("<" + name + "><rdf:" + kind + ">")
+	for _, it := range items {
+		prop.WriteString("<rdf:li>")
+		xml.EscapeText(&prop, []byte(it))
+		prop.WriteString("</rdf:li>")
+	}
+	prop.WriteString("</rdf:" + kind + "></" + name + ">")
+	return setXMPProperty(packet, name, uri, prop.String())
+}
+
+// setXMPProperty return packet with the property name replaced by the
+// element prop, or removed if prop is empty. uri is the namespace of the
+// name prefix, declared if the packet lack it. An empty packet start from
+// xmpTemplate.
+func setXMPProperty(packet []byte, name, uri, prop string) []byte {
+	s := string(bytes.Trim(packet, "\x00"))
+	if strings.TrimSpace(s) == "" {
+		s = xmpTemplate
+	}
+	s = removeXMPProperty(s, name)
+	if prop == "" {
+		return []byte(s)
+	}
+	i := strings.Index(s, "<rdf:Description")
+	if i < 0 {
+		if i = strings.Index(s, "<rdf:RDF"); i < 0 {
+			s, i = xmpTemplate, strings.Index(xmpTemplate, "<rdf:Description")
+		} else if j := strings.IndexByte(s[i:], '>'); j >= 0 {
+			s = s[:i+j+1] + `<rdf:Description rdf:about=""/>` + s[i+j+1:]
+			i += j + 1
+		}
+	}
+	end := i + strings.IndexByte(s[i:], '>') // end of the start tag
+	ns := ""
+	if prefix := name[:strings.IndexByte(name, ':')]; !strings.Contains(s, "xmlns:"+prefix+"=") {
+		ns = " xmlns:" + prefix + `="` + uri + `"`
+	}
+	if s[end-1] == '/' { // empty description
+		return []byte(s[:end-1] + ns + ">" + prop + "</rdf:Description>" + s[end+1:])
+	}
+	return []byte(s[:end] + ns + ">" + prop + s[end+1:])
+}
+
+// removeXMPProperty remove the elements of the property name from the packet s.
+func removeXMPProperty(s, name string) string {
+	for from := 0; ; {
+		i, end := xmpProperty(s, name, from)
+		if i < 0 {
+			return s
+		}
+		s, from = s[:i]+s[end:], i
+	}
+}
+
+// xmpProperty return the bounds of the first element of the property name
+// in the packet s from offset from on, i is -1 if there is none.
+func xmpProperty(s, name string, from int) (i, end int) {
+	for {
+		if i = strings.Index(s[from:], "<"+name); i < 0 {
+			return -1, 0
+		}
+		i += from
+		rest := s[i+1+len(name):]
+		if rest == "" || !strings.ContainsRune(" \t\r\n/>", rune(rest[0])) { // longer name
+			from = i + 1
+			continue
+		}
+		j := strings.IndexByte(rest, '>')
+		if j < 0 {
+			return -1, 0
+		}
+		end = i + 1 + len(name) + j + 1
+		if j == 0 || rest[j-1] != '/' {
+			k := strings.Index(s[end:], "</"+name+">")
+			if k < 0 {
+				return -1, 0
+			}
+			end += k + len(name) + 3
+		}
+		return
+	}
+}
+
+// xmp namespaces of the edit history
+const (
+	xmpMMNamespace = "http://ns.adobe.com/xap/1.0/mm/"
+	stEvtNamespace = "http://ns.adobe.com/xap/1.0/sType/ResourceEvent#"
+)
+
+// appendXMPHistory return packet with an event appended to its
+// xmpMM:History, recording that agent did action on the metadata at when.
+func appendXMPHistory(packet []byte, action, agent string, when time.Time) []byte {
+	s := string(bytes.Trim(packet, "\x00"))
+	var items string
+	if i, end := xmpProperty(s, "xmpMM:History", 0); i >= 0 {
+		h := s[i:end]
+		if j, k := strings.Index(h, "<rdf:Seq>"), strings.LastIndex(h, "</rdf:Seq>"); j >= 0 && k > j {
+			items = h[j+len("<rdf:Seq>") : k]
+		}
+	}
+	var prop strings.Builder
+	prop.WriteString("<xmpMM:History><rdf:Seq>" + items + "<rdf:li")
+	if !strings.Contains(s, "xmlns:stEvt=") {
+		prop.WriteString(` xmlns:stEvt="` + stEvtNamespace + `"`)
+	}
+	prop.WriteString(` stEvt:action="`)
+	xml.EscapeText(&prop, []byte(action))
+	prop.WriteString(`" stEvt:when="` + when.Format(time.RFC3339) + `" stEvt:softwareAgent="`)
+	xml.EscapeText(&prop, []byte(agent))
+	prop.WriteString(`" stEvt:changed="/metadata"/></rdf:Seq></xmpMM:History>`)
+	return setXMPProperty([]byte(s), "xmpMM:History", xmpMMNamespace, prop.String())
+}
+
+// setXMPPacket return in with its main xmp packet replaced by packet, which
+// is inserted after the exif or the JFIF APP0 if in has none.
+func setXMPPacket(in, packet []byte) (out []byte, err error) {
+	payload := append(append(make([]byte, 0, len(xmpPrefix)+len(packet)), xmpPrefix...), packet...)
+	if len(payload) > 0xffff-2 {
+		err = ErrInvalidBlockSize
+		return
+	}
+	seg := append([]byte{0xff, 0xe1, byte((len(payload) + 2) >> 8), byte(len(payload) + 2)}, payload...)
+	var segs []segment
+	if segs, _, err = readSegments(in); err != nil {
+		return
+	}
+	at := -1
+	for _, s := range segs {
+		if s.marker == markerAPP1 && bytes.HasPrefix(s.payload(in), xmpPrefix) {
+			return splice(in, s.offset, s.size, seg)
+		}
+		if at < 0 && isExif(in, s) {
+			at = s.offset + s.size
+		}
+	}
+	if at < 0 {
+		return InsertSegment(in, 0xe1, payload, AfterAPP0)
+	}
+	return splice(in, at, 0, seg)
+}
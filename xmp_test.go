@@ -0,0 +1,98 @@
+package exif
+
+import (
+	"encoding/binary"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testXMPJPEG build a jpeg holding packet in a xmp APP1.
+func testXMPJPEG(packet string) []byte {
+	out := []byte{0xff, 0xd8, 0xff, 0xe1, 0, 0}
+	binary.BigEndian.PutUint16(out[4:], uint16(2+len(xmpPrefix)+len(packet)))
+	out = append(out, xmpPrefix...)
+	out = append(out, packet...)
+	return append(out, 0xff, 0xd9)
+}
+
+func TestXMP(t *testing.T) {
+	src := readFixture(t, "jfif_bigEndian.jpg")
+	props, err := XMP(src, nil)
+	if err != nil {
+		t.Fatalf("XMP error(%v)", err)
+	}
+	if v := props["xmp:CreatorTool"]; len(v) != 1 || v[0] != "12.1.4" {
+		t.Fatalf("xmp:CreatorTool = %q, want 12.1.4", v)
+	}
+	packet := `<x:xmpmeta xmlns:x="adobe:ns:meta/"><rdf:RDF xmlns:rdf="http://www.w3.org/1999/02/22-rdf-syntax-ns#">
+<rdf:Description rdf:about="" xmlns:dc="http://purl.org/dc/elements/1.1/" xmlns:tiff="http://ns.adobe.com/tiff/1.0/">
+<tiff:Make>Canon</tiff:Make>
+<dc:subject><rdf:Bag><rdf:li>cat</rdf:li><rdf:li>dog</rdf:li></rdf:Bag></dc:subject>
+</rdf:Description></rdf:RDF></x:xmpmeta>`
+	if props, err = XMP(testXMPJPEG(packet), nil); err != nil {
+		t.Fatalf("XMP error(%v)", err)
+	}
+	want := map[string][]string{"tiff:Make": {"Canon"}, "dc:subject": {"cat", "dog"}}
+	if !reflect.DeepEqual(props, want) {
+		t.Fatalf("XMP = %v, want %v", props, want)
+	}
+	custom := XMPParserFunc(func(packet []byte) (map[string][]string, error) {
+		return map[string][]string{"raw": {string(packet)}}, nil
+	})
+	if props, err = XMP(testXMPJPEG(packet), custom); err != nil || props["raw"][0] != packet {
+		t.Fatalf("XMP(custom) = %v, %v", props, err)
+	}
+	nested := `<x:xmpmeta xmlns:x="adobe:ns:meta/"><rdf:RDF xmlns:rdf="http://www.w3.org/1999/02/22-rdf-syntax-ns#">
+<rdf:Description rdf:about="" xmlns:dc="http://purl.org/dc/elements/1.1/"><dc:a>1</dc:a><dc:nest><rdf:Description dc:at="x"><dc:in>2</dc:in></rdf:Description></dc:nest><dc:b>3</dc:b></rdf:Description>
+</rdf:RDF></x:xmpmeta>`
+	if props, err = XMP(testXMPJPEG(nested), nil); err != nil {
+		t.Fatalf("XMP(nested) error(%v)", err)
+	}
+	if want = map[string][]string{"dc:a": {"1"}, "dc:b": {"3"}}; !reflect.DeepEqual(props, want) {
+		t.Fatalf("XMP(nested) = %v, want %v", props, want)
+	}
+	src = readFixture(t, filename)
+	if _, err = XMP(src, nil); err != ErrNoXMP {
+		t.Fatalf("XMP(%s) error(%v), want %v", filename, err, ErrNoXMP)
+	}
+}
+
+func TestSetXMPArray(t *testing.T) {
+	for _, packet := range []string{
+		"",
+		`<x:xmpmeta xmlns:x="adobe:ns:meta/"><rdf:RDF xmlns:rdf="http://www.w3.org/1999/02/22-rdf-syntax-ns#"><rdf:Description rdf:about="" xmlns:xmp="http://ns.adobe.com/xap/1.0/" xmp:Rating="3"/></rdf:RDF></x:xmpmeta>`,
+		`<x:xmpmeta xmlns:x="adobe:ns:meta/"><rdf:RDF xmlns:rdf="http://www.w3.org/1999/02/22-rdf-syntax-ns#"><rdf:Description rdf:about="" xmlns:dc="http://purl.org/dc/elements/1.1/"><dc:subjects>x</dc:subjects><dc:subject><rdf:Bag><rdf:li>old</rdf:li></rdf:Bag></dc:subject><dc:subject/></rdf:Description></rdf:RDF></x:xmpmeta>`,
+	} {
+		out := setXMPArray([]byte(packet), "dc:subject", dcNamespace, "Bag", []string{"a & b", "c"})
+		props, err := parseXMP(out)
+		if err != nil {
+			t.Fatalf("parseXMP(%s) error(%v)", out, err)
+		}
+		if got := props["dc:subject"]; !reflect.DeepEqual(got, []string{"a & b", "c"}) {
+			t.Fatalf("dc:subject = %q in %s", got, out)
+		}
+		if strings.Contains(packet, "Rating") && props["xmp:Rating"][0] != "3" {
+			t.Fatalf("xmp:Rating lost in %s", out)
+		}
+		if strings.Contains(packet, "subjects") && props["dc:subjects"][0] != "x" {
+			t.Fatalf("dc:subjects lost in %s", out)
+		}
+		if props, _ = parseXMP(setXMPArray(out, "dc:subject", dcNamespace, "Bag", nil)); props["dc:subject"] != nil {
+			t.Fatalf("dc:subject = %q after removal", props["dc:subject"])
+		}
+	}
+}
+
+func TestAppendXMPHistory(t *testing.T) {
+	packet := `<x:xmpmeta xmlns:x="adobe:ns:meta/"><rdf:RDF xmlns:rdf="http://www.w3.org/1999/02/22-rdf-syntax-ns#">` +
+		`<rdf:Description rdf:about="" xmlns:xmpMM="http://ns.adobe.com/xap/1.0/mm/" xmlns:stEvt="http://ns.adobe.com/xap/1.0/sType/ResourceEvent#">` +
+		`<xmpMM:History><rdf:Seq><rdf:li stEvt:action="created"/></rdf:Seq></xmpMM:History></rdf:Description></rdf:RDF></x:xmpmeta>`
+	when := time.Date(2026, 10, 14, 9, 30, 0, 0, time.UTC)
+	got := string(appendXMPHistory([]byte(packet), "saved", "tool", when))
+	want := `<xmpMM:History><rdf:Seq><rdf:li stEvt:action="created"/><rdf:li stEvt:action="saved" stEvt:when="2026-10-14T09:30:00Z" stEvt:softwareAgent="tool" stEvt:changed="/metadata"/></rdf:Seq></xmpMM:History>`
+	if !strings.Contains(got, want) || strings.Count(got, "xmpMM:History>") != 2 {
+		t.Fatalf("appendXMPHistory = %s, want %s", got, want)
+	}
+}